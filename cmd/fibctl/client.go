@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	ihttp "github.com/deividaspetraitis/fibonacci/http"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+// errUnavailable represents an error returned when server could not be reached.
+var errUnavailable = errors.New("server unavailable")
+
+// responseError represents an error response returned by the server.
+type responseError struct {
+	StatusCode int
+	Body       api.Error
+}
+
+// Error implements error interface.
+func (e *responseError) Error() string {
+	if e.Body.Message == "" {
+		return fmt.Sprintf("server responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("server responded with error: %s", e.Body.Message)
+}
+
+// client talks to a running serverd instance.
+type client struct {
+	base   string
+	apiKey string
+	http   *http.Client
+}
+
+// newClient constructs a new client for server available at base URL authenticating with apiKey if not empty.
+func newClient(base, apiKey string) *client {
+	return &client{
+		base:   strings.TrimSuffix(base, "/"),
+		apiKey: apiKey,
+		http:   http.DefaultClient,
+	}
+}
+
+// header returns headers sent along with every request.
+func (c *client) header() http.Header {
+	header := make(http.Header)
+	if c.apiKey != "" {
+		header.Set(ihttp.APIKeyHeader, c.apiKey)
+	}
+	return header
+}
+
+// Step calls one of current, next or previous endpoints and returns the number it responded with.
+func (c *client) Step(ctx context.Context, command string) (int64, error) {
+	switch command {
+	case "current":
+		var resp api.CurrentFibonacciNumberResponse
+		err := c.get(ctx, "/v1/current", &resp)
+		return resp.Current, err
+	case "next":
+		var resp api.NextFibonacciNumberResponse
+		err := c.get(ctx, "/v1/next", &resp)
+		return resp.Next, err
+	case "previous":
+		var resp api.PreviousFibonacciNumberResponse
+		err := c.get(ctx, "/v1/previous", &resp)
+		return resp.Previous, err
+	default:
+		return 0, errors.Newf("unknown step command %q", command)
+	}
+}
+
+// Reset moves the server counter back to the lowest allowed term and returns its number.
+func (c *client) Reset(ctx context.Context) (int64, error) {
+	var resp api.CurrentFibonacciNumberResponse
+	err := c.do(ctx, http.MethodPost, "/v1/reset", &resp)
+	return resp.Current, err
+}
+
+// get requests path and unmarshals successful response into v.
+func (c *client) get(ctx context.Context, path string, v ihttp.ResponseUnmarshaler) error {
+	return c.do(ctx, http.MethodGet, path, v)
+}
+
+// do makes request to path using method and unmarshals successful response into v.
+func (c *client) do(ctx context.Context, method, path string, v ihttp.ResponseUnmarshaler) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, nil)
+	if err != nil {
+		return errors.Wrapf(err, "building request %s", path)
+	}
+	req.Header = c.header()
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrap(errUnavailable, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respErr := responseError{StatusCode: resp.StatusCode}
+		// Error body is optional, status code is enough to report failure.
+		ihttp.UnmarshalResponse(resp, &respErr.Body)
+		return &respErr
+	}
+
+	if err := ihttp.UnmarshalResponse(resp, v); err != nil {
+		return errors.Wrapf(err, "decoding %s response", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+	ihttp "github.com/deividaspetraitis/fibonacci/http"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// newTestServer starts API server with the counter pointing to n th term.
+// Reader key is granted read scope and writer key both read and write scopes.
+func newTestServer(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+	return newTestServerConfig(t, fibonacci.DefaultConfig(), n)
+}
+
+// newTestServerConfig starts API server with the counter configured by cfg pointing to n th term, see newTestServer.
+func newTestServerConfig(t *testing.T, cfg fibonacci.Config, n int) *httptest.Server {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := `{"keys": [
+		{"name": "reader", "hash": "` + ihttp.HashAPIKey("reader-key") + `", "scopes": ["read"]},
+		{"name": "writer", "hash": "` + ihttp.HashAPIKey("writer-key") + `", "scopes": ["read", "write"]}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	keys, err := ihttp.NewAPIKeys(path)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var sequence fibonacci.Fibonacci
+	if err := sequence.SetConfig(cfg); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if _, err := sequence.SeekFibonacciNumber(context.TODO(), n); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	app := ihttp.NewApp(make(chan os.Signal, 1), log.Discard())
+	server := httptest.NewServer(ihttp.API(app, &ihttp.Config{}, &sequence, ihttp.NewMetrics(&sequence), ihttp.NewHealth(), keys))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientStep(t *testing.T) {
+	var testcases = []struct {
+		command string
+		apiKey  string
+		term    int // counter position before the command
+
+		expected int64
+		code     int
+	}{
+		{command: "current", apiKey: "reader-key", term: 10, expected: 55, code: exitOK},
+		{command: "next", apiKey: "writer-key", term: 10, expected: 89, code: exitOK},
+		{command: "previous", apiKey: "writer-key", term: 10, expected: 34, code: exitOK},
+		{command: "next", apiKey: "writer-key", term: fibonacci.MaxThTerm, code: exitOverflow},
+		{command: "previous", apiKey: "writer-key", term: 0, code: exitUnderflow},
+		{command: "next", apiKey: "reader-key", term: 10, code: exitDenied},
+		{command: "current", term: 10, code: exitDenied},
+		{command: "current", apiKey: "unknown-key", term: 10, code: exitDenied},
+		{command: "jump", apiKey: "writer-key", term: 10, code: exitError},
+	}
+
+	for i, tt := range testcases {
+		server := newTestServer(t, tt.term)
+
+		number, err := newClient(server.URL+"/", tt.apiKey).Step(context.TODO(), tt.command)
+		if code := exitCode(err); code != tt.code {
+			t.Errorf("#%d exit code got %v, want %v (%v)", i, code, tt.code, err)
+		}
+		if number != tt.expected {
+			t.Errorf("#%d got %v, want %v", i, number, tt.expected)
+		}
+	}
+}
+
+func TestClientReset(t *testing.T) {
+	var testcases = []struct {
+		apiKey string
+
+		expected int64
+		code     int
+	}{
+		{apiKey: "writer-key", expected: 5, code: exitOK},
+		{apiKey: "reader-key", code: exitDenied},
+		{code: exitDenied},
+	}
+
+	for i, tt := range testcases {
+		// counter resets to the lowest allowed term, not to the first one
+		server := newTestServerConfig(t, fibonacci.Config{Min: 5, Max: 20}, 10)
+
+		number, err := newClient(server.URL, tt.apiKey).Reset(context.TODO())
+		if code := exitCode(err); code != tt.code {
+			t.Errorf("#%d exit code got %v, want %v (%v)", i, code, tt.code, err)
+		}
+		if number != tt.expected {
+			t.Errorf("#%d got %v, want %v", i, number, tt.expected)
+		}
+	}
+}
+
+func TestClientUnavailable(t *testing.T) {
+	server := newTestServer(t, 10)
+	c := newClient(server.URL, "writer-key")
+	server.Close()
+
+	if _, err := c.Step(context.TODO(), "current"); exitCode(err) != exitUnavailable {
+		t.Errorf("got %v, want %v (%v)", exitCode(err), exitUnavailable, err)
+	}
+	if _, err := c.Reset(context.TODO()); exitCode(err) != exitUnavailable {
+		t.Errorf("got %v, want %v (%v)", exitCode(err), exitUnavailable, err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// program exit codes
+const (
+	exitOK          = 0 // command succeeded
+	exitError       = 1 // unclassified error
+	exitUsage       = 2 // invalid command line usage
+	exitOverflow    = 3 // counter overflow, api.Error "counter overflow"
+	exitUnderflow   = 4 // counter underflow, api.Error "counter underflow"
+	exitUnavailable = 5 // server is unreachable or failed to respond
+	exitDenied      = 6 // server rejected API key, 401 Unauthorized or 403 Forbidden
+)
+
+// errUsage represents an error caused by invalid command line usage.
+var errUsage = errors.New("invalid usage")
+
+// program flags
+var (
+	server  string
+	apiKey  string
+	format  string
+	timeout time.Duration
+)
+
+// initialise program state
+func init() {
+	flag.StringVar(&server, "server", envOr("FIBCTL_SERVER", "http://localhost:8000"), "URL of running serverd instance")
+	flag.StringVar(&apiKey, "api-key", os.Getenv("FIBCTL_API_KEY"), "API key to authenticate to the server with")
+	flag.StringVar(&format, "output", "plain", "output format: json, table or plain")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "server request timeout")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), `Usage: fibctl [flags] <command> [args]
+
+Server commands:
+  current           print the current number in the sequence
+  next              move the counter forward and print the next number
+  previous          move the counter backward and print the previous number
+  reset             move the counter back to the lowest allowed term
+
+Local commands, no server required:
+  term <n>          print n th term of the sequence
+  range <from> <to> print terms from..to inclusive
+
+Flags:
+`)
+		flag.PrintDefaults()
+	}
+}
+
+// main program entry point.
+func main() {
+	flag.Parse()
+
+	out, err := newPrinter(os.Stdout, format)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := run(ctx, newClient(server, apiKey), flag.Args())
+	if err != nil {
+		fail(err)
+	}
+
+	if err := out.Print(res); err != nil {
+		fail(err)
+	}
+}
+
+// run executes command given by args and returns its result.
+func run(ctx context.Context, c *client, args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, errors.Wrap(errUsage, "command is required")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "current", "next", "previous":
+		if len(args) != 0 {
+			return nil, errors.Wrapf(errUsage, "%s accepts no arguments", command)
+		}
+		number, err := c.Step(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+		return newValueResult(command, number), nil
+
+	case "reset":
+		if len(args) != 0 {
+			return nil, errors.Wrapf(errUsage, "%s accepts no arguments", command)
+		}
+		number, err := c.Reset(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newValueResult("current", number), nil
+
+	case "term":
+		if len(args) != 1 {
+			return nil, errors.Wrap(errUsage, "term requires exactly one argument")
+		}
+		n, err := parseTerm(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return termRange(n, n)
+
+	case "range":
+		if len(args) != 2 {
+			return nil, errors.Wrap(errUsage, "range requires exactly two arguments")
+		}
+		from, err := parseTerm(args[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTerm(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if from > to {
+			return nil, errors.Wrapf(errUsage, "range start %d is after its end %d", from, to)
+		}
+		return termRange(from, to)
+
+	default:
+		return nil, errors.Wrapf(errUsage, "unknown command %q", command)
+	}
+}
+
+// termRange computes terms from..to inclusive locally.
+func termRange(from, to int) (*result, error) {
+	terms := make([]termValue, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		number, err := fibonacci.Term(n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "computing %dth term", n)
+		}
+		terms = append(terms, termValue{Term: n, Value: number})
+	}
+	return newTermsResult(terms), nil
+}
+
+// parseTerm parses s as a term number in the sequence.
+func parseTerm(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(errUsage, "invalid term %q", s)
+	}
+	return n, nil
+}
+
+// fail prints err and terminates the program with exit code mapped from err.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "fibctl: %v\n", err)
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+	}
+	os.Exit(exitCode(err))
+}
+
+// exitCode maps err to the program exit code.
+func exitCode(err error) int {
+	var respErr *responseError
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, fibonacci.ErrCounterOverflow):
+		return exitOverflow
+	case errors.Is(err, fibonacci.ErrCounterUnderflow):
+		return exitUnderflow
+	case errors.As(err, &respErr):
+		if respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden {
+			return exitDenied
+		}
+		switch respErr.Body.Message {
+		case "counter overflow":
+			return exitOverflow
+		case "counter underflow":
+			return exitUnderflow
+		case "forbidden":
+			return exitDenied
+		}
+		return exitUnavailable
+	case errors.Is(err, errUnavailable):
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
+
+// envOr returns value of environment variable key or fallback when it is not set.
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
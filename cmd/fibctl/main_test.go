@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+func TestRun(t *testing.T) {
+	var testcases = []struct {
+		args []string
+
+		output string // plain output
+		code   int
+	}{
+		{args: []string{"current"}, output: "55\n"},
+		{args: []string{"next"}, output: "89\n"},
+		{args: []string{"reset"}, output: "0\n"},
+		{args: []string{"term", "10"}, output: "55\n"},
+		{args: []string{"range", "3", "6"}, output: "2\n3\n5\n8\n"},
+		{args: nil, code: exitUsage},
+		{args: []string{"current", "1"}, code: exitUsage},
+		{args: []string{"term", "ten"}, code: exitUsage},
+		{args: []string{"range", "6", "3"}, code: exitUsage},
+		{args: []string{"jump"}, code: exitUsage},
+		{args: []string{"term", "93"}, code: exitOverflow},
+		{args: []string{"term", "-1"}, code: exitUnderflow},
+	}
+
+	for i, tt := range testcases {
+		server := newTestServer(t, 10)
+
+		res, err := run(context.TODO(), newClient(server.URL, "writer-key"), tt.args)
+		if code := exitCode(err); code != tt.code {
+			t.Errorf("#%d exit code got %v, want %v (%v)", i, code, tt.code, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		var out bytes.Buffer
+		if err := (&plainPrinter{w: &out}).Print(res); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		}
+		if out.String() != tt.output {
+			t.Errorf("#%d got %q, want %q", i, out.String(), tt.output)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	var testcases = []struct {
+		err error
+
+		code int
+	}{
+		{err: nil, code: exitOK},
+		{err: errors.New("unexpected"), code: exitError},
+		{err: errors.Wrap(errUsage, "command is required"), code: exitUsage},
+		{err: errors.Wrap(fibonacci.ErrCounterOverflow, "computing 93th term"), code: exitOverflow},
+		{err: fibonacci.ErrCounterUnderflow, code: exitUnderflow},
+		{err: errors.Wrap(errUnavailable, "connection refused"), code: exitUnavailable},
+		{err: &responseError{StatusCode: http.StatusBadRequest, Body: api.Error{Message: "counter overflow"}}, code: exitOverflow},
+		{err: &responseError{StatusCode: http.StatusBadRequest, Body: api.Error{Message: "counter underflow"}}, code: exitUnderflow},
+		{err: &responseError{StatusCode: http.StatusUnauthorized}, code: exitDenied},
+		{err: &responseError{StatusCode: http.StatusForbidden, Body: api.Error{Message: "forbidden"}}, code: exitDenied},
+		{err: &responseError{StatusCode: http.StatusTooManyRequests}, code: exitUnavailable},
+		{err: &responseError{StatusCode: http.StatusInternalServerError}, code: exitUnavailable},
+	}
+
+	for i, tt := range testcases {
+		if code := exitCode(tt.err); code != tt.code {
+			t.Errorf("#%d got %v, want %v", i, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// termValue represents n th term of the sequence computed locally.
+type termValue struct {
+	Term  int   `json:"term"`
+	Value int64 `json:"value"`
+}
+
+// result represents command result printable in any of the supported output formats.
+type result struct {
+	json   interface{} // value encoded in json format
+	header []string    // table header
+	rows   [][]string  // table rows, plain format prints last column only
+}
+
+// newValueResult constructs a result of a single number named name, e.g. current.
+func newValueResult(name string, number int64) *result {
+	return &result{
+		json:   map[string]int64{name: number},
+		header: []string{strings.ToUpper(name)},
+		rows:   [][]string{{strconv.FormatInt(number, 10)}},
+	}
+}
+
+// newTermsResult constructs a result of terms.
+func newTermsResult(terms []termValue) *result {
+	res := result{
+		json:   terms,
+		header: []string{"TERM", "VALUE"},
+	}
+	for _, t := range terms {
+		res.rows = append(res.rows, []string{strconv.Itoa(t.Term), strconv.FormatInt(t.Value, 10)})
+	}
+	return &res
+}
+
+// printer prints results in the chosen output format.
+type printer interface {
+	Print(res *result) error
+}
+
+// newPrinter constructs a printer writing to w in the given format.
+func newPrinter(w io.Writer, format string) (printer, error) {
+	switch format {
+	case "json":
+		return &jsonPrinter{w: w}, nil
+	case "table":
+		return &tablePrinter{w: w}, nil
+	case "plain":
+		return &plainPrinter{w: w}, nil
+	default:
+		return nil, errors.Wrapf(errUsage, "unknown output format %q", format)
+	}
+}
+
+// jsonPrinter prints results as JSON.
+type jsonPrinter struct {
+	w io.Writer
+}
+
+// Print implements printer.
+func (p *jsonPrinter) Print(res *result) error {
+	return json.NewEncoder(p.w).Encode(res.json)
+}
+
+// tablePrinter prints results as aligned table having a header.
+type tablePrinter struct {
+	w io.Writer
+}
+
+// Print implements printer.
+func (p *tablePrinter) Print(res *result) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(res.header, "\t"))
+	for _, row := range res.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// plainPrinter prints bare values, one per line, suitable for scripting.
+type plainPrinter struct {
+	w io.Writer
+}
+
+// Print implements printer.
+func (p *plainPrinter) Print(res *result) error {
+	for _, row := range res.rows {
+		if _, err := fmt.Fprintln(p.w, row[len(row)-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
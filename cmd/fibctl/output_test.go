@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+func TestPrinter(t *testing.T) {
+	value := newValueResult("current", 55)
+	terms := newTermsResult([]termValue{{Term: 9, Value: 34}, {Term: 10, Value: 55}})
+
+	var testcases = []struct {
+		format string
+		res    *result
+
+		output string
+	}{
+		{format: "plain", res: value, output: "55\n"},
+		{format: "plain", res: terms, output: "34\n55\n"},
+		{format: "json", res: value, output: `{"current":55}` + "\n"},
+		{format: "json", res: terms, output: `[{"term":9,"value":34},{"term":10,"value":55}]` + "\n"},
+		{format: "table", res: value, output: "CURRENT\n55\n"},
+		{format: "table", res: terms, output: "TERM  VALUE\n9     34\n10    55\n"},
+	}
+
+	for i, tt := range testcases {
+		var out bytes.Buffer
+		p, err := newPrinter(&out, tt.format)
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+
+		if err := p.Print(tt.res); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		}
+		if out.String() != tt.output {
+			t.Errorf("#%d got %q, want %q", i, out.String(), tt.output)
+		}
+	}
+
+	if _, err := newPrinter(&bytes.Buffer{}, "yaml"); !errors.Is(err, errUsage) {
+		t.Errorf("got %v, want %v", err, errUsage)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strconv"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// binaryMagic prefixes every file written in binary format.
+var binaryMagic = []byte("FIBG")
+
+// binaryVersion is current version of binary format.
+const binaryVersion = 1
+
+// encoder encodes a range of the sequence terms into one of the supported output formats.
+type encoder interface {
+	// Header returns bytes written once at the beginning of the output.
+	Header(from, to uint64) []byte
+
+	// Record appends encoded n th term v to buf and returns the extended buffer.
+	Record(buf []byte, n uint64, v *big.Int) []byte
+}
+
+// newEncoder constructs an encoder for the given format.
+func newEncoder(format string) (encoder, error) {
+	switch format {
+	case "csv":
+		return csvEncoder{}, nil
+	case "ndjson":
+		return ndjsonEncoder{}, nil
+	case "binary":
+		return binaryEncoder{}, nil
+	default:
+		return nil, errors.Newf("unknown format %q", format)
+	}
+}
+
+// csvEncoder encodes terms as comma separated rows having term,value header.
+type csvEncoder struct{}
+
+// Header implements encoder.
+func (csvEncoder) Header(from, to uint64) []byte {
+	return []byte("term,value\n")
+}
+
+// Record implements encoder.
+func (csvEncoder) Record(buf []byte, n uint64, v *big.Int) []byte {
+	buf = strconv.AppendUint(buf, n, 10)
+	buf = append(buf, ',')
+	buf = v.Append(buf, 10)
+	return append(buf, '\n')
+}
+
+// ndjsonEncoder encodes terms as newline delimited JSON objects.
+// Values are string encoded since they do not fit into JSON numbers supported by most decoders.
+type ndjsonEncoder struct{}
+
+// Header implements encoder.
+func (ndjsonEncoder) Header(from, to uint64) []byte {
+	return nil
+}
+
+// Record implements encoder.
+func (ndjsonEncoder) Record(buf []byte, n uint64, v *big.Int) []byte {
+	buf = append(buf, `{"term":`...)
+	buf = strconv.AppendUint(buf, n, 10)
+	buf = append(buf, `,"value":"`...)
+	buf = v.Append(buf, 10)
+	return append(buf, "\"}\n"...)
+}
+
+// binaryEncoder encodes terms in compact binary format:
+//
+//	header: "FIBG" | version byte | uvarint from | uvarint to
+//	record: uvarint length | big-endian value bytes
+//
+// Term numbers are implicit, i th record holds from+i th term.
+type binaryEncoder struct{}
+
+// Header implements encoder.
+func (binaryEncoder) Header(from, to uint64) []byte {
+	buf := append([]byte{}, binaryMagic...)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, from)
+	return binary.AppendUvarint(buf, to)
+}
+
+// Record implements encoder.
+func (binaryEncoder) Record(buf []byte, n uint64, v *big.Int) []byte {
+	b := v.Bytes()
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/deividaspetraitis/fibonacci"
+)
+
+// chunk represents a contiguous range of terms generated by a single worker.
+type chunk struct {
+	from, to uint64 // inclusive
+	data     []byte
+	sum      string
+}
+
+// chunks splits from..to inclusive range into chunks of at most size terms, whose encoding takes at most
+// maxBytes bytes as estimated by recordBound. Chunk holds at least one term however large it is.
+// No chunks are returned when the range is empty, i.e. from is after to.
+func chunks(from, to, size, maxBytes uint64) []chunk {
+	var cs []chunk
+	if from > to {
+		return cs
+	}
+	for start := from; ; {
+		end := start + size - 1
+		if end > to || end < start { // end < start guards against overflow
+			end = to
+		}
+
+		// terms grow along the sequence, so none of the chunk is larger than its last one
+		if n := maxBytes / recordBound(end); n < end-start+1 {
+			end = start + n - 1
+			if n == 0 {
+				end = start
+			}
+		}
+
+		cs = append(cs, chunk{from: start, to: end})
+		if end == to {
+			return cs
+		}
+		start = end + 1
+	}
+}
+
+// log10Phi is a logarithm of the golden ratio, n th term of the sequence is less than phi^n.
+const log10Phi = 0.20898764024997873
+
+// recordBound returns upper bound of bytes n th term is encoded into by any of the encoders.
+// Decimal digits of the value outnumber its bytes, the rest is taken by term number and record framing.
+func recordBound(n uint64) uint64 {
+	return uint64(float64(n)*log10Phi) + 64
+}
+
+// encodeChunk generates and encodes terms of c.
+// The first pair of terms is seeded via fast doubling, remaining ones are calculated by addition.
+// Nothing is encoded for empty chunk.
+func encodeChunk(enc encoder, c chunk) chunk {
+	if c.from > c.to {
+		return c
+	}
+
+	a, b := fibonacci.BigTermPair(c.from)
+	for n := c.from; ; n++ {
+		c.data = enc.Record(c.data, n, a)
+		if n == c.to {
+			break
+		}
+		a.Add(a, b)
+		a, b = b, a
+	}
+
+	sum := sha256.Sum256(c.data)
+	c.sum = hex.EncodeToString(sum[:])
+	return c
+}
+
+// generate encodes cs in parallel using workers goroutines and passes them to commit in order.
+// At most workers chunks are kept in memory waiting to be committed.
+func generate(ctx context.Context, enc encoder, cs []chunk, workers int, commit func(c chunk) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// order preserves chunk order, its capacity bounds number of chunks in flight.
+	order := make(chan chan chunk, workers)
+
+	go func() {
+		defer close(order)
+
+		sem := make(chan struct{}, workers)
+		for _, c := range cs {
+			done := make(chan chunk, 1)
+			select {
+			case order <- done:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(c chunk) {
+				defer func() { <-sem }()
+				done <- encodeChunk(enc, c)
+			}(c)
+		}
+	}()
+
+	for done := range order {
+		var c chunk
+		select {
+		case c = <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err := commit(c); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+)
+
+func TestChunks(t *testing.T) {
+	var testcases = []struct {
+		from, to, size, maxBytes uint64
+
+		expected []chunk
+	}{
+		{
+			from: 0, to: 9, size: 5, maxBytes: 1 << 20,
+			expected: []chunk{{from: 0, to: 4}, {from: 5, to: 9}},
+		},
+		{
+			from: 3, to: 10, size: 5, maxBytes: 1 << 20,
+			expected: []chunk{{from: 3, to: 7}, {from: 8, to: 10}},
+		},
+		{
+			from: 7, to: 7, size: 5, maxBytes: 1 << 20,
+			expected: []chunk{{from: 7, to: 7}},
+		},
+		{
+			from: 11, to: 10, size: 5, maxBytes: 1 << 20,
+			expected: nil,
+		},
+		{
+			from: 0, to: 9, size: 5, maxBytes: 130, // terms up to 9th are bound to at most 65 bytes
+			expected: []chunk{{from: 0, to: 1}, {from: 2, to: 3}, {from: 4, to: 5}, {from: 6, to: 7}, {from: 8, to: 9}},
+		},
+		{
+			from: 1000, to: 1001, size: 5, maxBytes: 1, // single term exceeds the bound
+			expected: []chunk{{from: 1000, to: 1000}, {from: 1001, to: 1001}},
+		},
+	}
+
+	for i, tt := range testcases {
+		got := chunks(tt.from, tt.to, tt.size, tt.maxBytes)
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("#%d got %v, want %v", i, got, tt.expected)
+		}
+	}
+}
+
+func TestChunksBytes(t *testing.T) {
+	const maxBytes = 1 << 14
+
+	for _, enc := range []encoder{csvEncoder{}, ndjsonEncoder{}, binaryEncoder{}} {
+		cs := chunks(0, 5000, 5000, maxBytes)
+		if len(cs) < 2 {
+			t.Fatalf("%T got %d chunks, want split by bytes", enc, len(cs))
+		}
+
+		err := generate(context.TODO(), enc, cs, 4, func(c chunk) error {
+			if len(c.data) > maxBytes {
+				t.Errorf("%T terms %d..%d got %d bytes, want at most %d", enc, c.from, c.to, len(c.data), maxBytes)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%T got %v, want %v", enc, err, nil)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	enc := csvEncoder{}
+
+	// sequential reference output
+	var expected []byte
+	for n := 0; n <= fibonacci.MaxThTerm; n++ {
+		fn, _ := fibonacci.BigTermPair(uint64(n))
+		expected = enc.Record(expected, uint64(n), fn)
+	}
+
+	var got bytes.Buffer
+	err := generate(context.TODO(), enc, chunks(0, fibonacci.MaxThTerm, 7, 1<<20), 4, func(c chunk) error {
+		got.Write(c.data)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if !bytes.Equal(got.Bytes(), expected) {
+		t.Errorf("got %s, want %s", got.Bytes(), expected)
+	}
+}
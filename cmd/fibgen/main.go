@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// program flags
+var (
+	from       uint64
+	to         uint64
+	format     string
+	output     string
+	workers    int
+	chunkSize  uint64
+	chunkBytes uint64
+	resume     bool
+)
+
+// initialise program state
+func init() {
+	flag.Uint64Var(&from, "from", 0, "first term of the range to generate")
+	flag.Uint64Var(&to, "to", 1000, "last term of the range to generate, inclusive")
+	flag.StringVar(&format, "format", "csv", "output format: csv, ndjson or binary")
+	flag.StringVar(&output, "o", "", "PATH to output file, checksum manifest is written next to it")
+	flag.IntVar(&workers, "workers", runtime.GOMAXPROCS(0), "number of chunks generated in parallel")
+	flag.Uint64Var(&chunkSize, "chunk", 10000, "number of terms generated by a single worker at once")
+	flag.Uint64Var(&chunkBytes, "chunk-bytes", 64<<20, "upper bound of bytes terms generated by a single worker at once are encoded into")
+	flag.BoolVar(&resume, "resume", false, "continue interrupted generation recorded in the manifest")
+}
+
+// main program entry point.
+func main() {
+	flag.Parse()
+
+	logger := log.Default()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, logger); err != nil {
+		logger.WithError(err).Fatal("unable to generate terms")
+	}
+}
+
+func run(ctx context.Context, logger log.Logger) error {
+	switch {
+	case output == "":
+		return errors.New("output file is required")
+	case from > to:
+		return errors.Newf("range start %d is after its end %d", from, to)
+	case workers < 1:
+		return errors.Newf("invalid number of workers %d", workers)
+	case chunkSize < 1:
+		return errors.Newf("invalid chunk size %d", chunkSize)
+	case chunkBytes < 1:
+		return errors.Newf("invalid chunk bytes %d", chunkBytes)
+	}
+
+	enc, err := newEncoder(format)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := output + ".manifest.json"
+	want := manifest{
+		Format:     format,
+		From:       from,
+		To:         to,
+		ChunkSize:  chunkSize,
+		ChunkBytes: chunkBytes,
+	}
+
+	// =========================================================================
+	// Prepare output
+
+	var m *manifest
+	if resume {
+		m, err = readManifest(manifestPath)
+		switch {
+		case os.IsNotExist(err):
+			logger.Printf("manifest %s not found, starting from scratch", manifestPath)
+			m = nil
+		case err != nil:
+			return err
+		case !m.matches(&want):
+			return errors.Newf("manifest %s was written for different parameters", manifestPath)
+		case m.Complete:
+			logger.Printf("%s is already complete", output)
+			return nil
+		}
+	}
+
+	var f *os.File
+	if m != nil {
+		f, err = openResumed(output, m.Size)
+		if err != nil {
+			return err
+		}
+		logger.Printf("resuming %s from %dth term", output, m.Next)
+	} else {
+		m = &want
+		f, err = openFresh(output, enc.Header(from, to))
+		if err != nil {
+			return err
+		}
+		m.Next = from
+		m.Size, _ = f.Seek(0, io.SeekCurrent)
+		if err := writeManifest(manifestPath, m); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	defer f.Close()
+
+	// =========================================================================
+	// Generate
+
+	commit := func(c chunk) error {
+		if _, err := f.Write(c.data); err != nil {
+			return errors.Wrapf(err, "writing terms %d..%d", c.from, c.to)
+		}
+
+		// Manifest must never get ahead of data persisted in the output.
+		if err := f.Sync(); err != nil {
+			return errors.Wrapf(err, "syncing %s", output)
+		}
+
+		m.Chunks = append(m.Chunks, chunkSum{
+			From:   c.from,
+			To:     c.to,
+			Offset: m.Size,
+			Size:   len(c.data),
+			SHA256: c.sum,
+		})
+		m.Size += int64(len(c.data))
+		m.Next = c.to + 1
+
+		logger.Debugf("committed terms %d..%d", c.from, c.to)
+
+		return writeManifest(manifestPath, m)
+	}
+
+	// Interrupted after the last chunk was committed but before the manifest was finalised.
+	if m.Next > to {
+		logger.Printf("all terms of %s are already committed", output)
+	} else if err := generate(ctx, enc, chunks(m.Next, to, chunkSize, chunkBytes), workers, commit); err != nil {
+		return errors.Wrap(err, "generation interrupted, rerun with -resume to continue")
+	}
+
+	// =========================================================================
+	// Finalise manifest
+
+	sum, err := checksum(output)
+	if err != nil {
+		return err
+	}
+	m.SHA256 = sum
+	m.Complete = true
+
+	if err := writeManifest(manifestPath, m); err != nil {
+		return err
+	}
+
+	logger.Printf("generated terms %d..%d into %s sha256:%s", from, to, output, sum)
+
+	return nil
+}
+
+// openFresh creates or truncates file at path and writes header into it.
+func openFresh(path string, header []byte) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s", path)
+	}
+
+	if _, err := f.Write(header); err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "writing header %s", path)
+	}
+
+	return f, nil
+}
+
+// openResumed opens file at path and discards everything written after size bytes.
+func openResumed(path string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "stat %s", path)
+	}
+	if info.Size() < size {
+		f.Close()
+		return nil, errors.Newf("%s is shorter than recorded in the manifest", path)
+	}
+
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "truncating %s", path)
+	}
+
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "seeking %s", path)
+	}
+
+	return f, nil
+}
+
+// checksum returns hex encoded SHA-256 checksum of file at path.
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening %s", path)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "reading %s", path)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+func TestRunResume(t *testing.T) {
+	from, to, format, workers, chunkSize, resume = 0, 10, "csv", 2, 4, false
+	output = filepath.Join(t.TempDir(), "terms.csv")
+	manifestPath := output + ".manifest.json"
+
+	if err := run(context.TODO(), log.Discard()); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	expected, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		name   string
+		modify func(m *manifest) // simulates where generation was interrupted
+	}{
+		{
+			name: "before last chunk was committed",
+			modify: func(m *manifest) {
+				last := m.Chunks[len(m.Chunks)-1]
+				m.Chunks = m.Chunks[:len(m.Chunks)-1]
+				m.Next, m.Size = last.From, last.Offset
+				m.SHA256, m.Complete = "", false
+			},
+		},
+		{
+			name: "before manifest was finalised",
+			modify: func(m *manifest) {
+				m.SHA256, m.Complete = "", false
+			},
+		},
+	}
+
+	resume = true
+	defer func() { resume = false }()
+
+	for _, tt := range testcases {
+		m, err := readManifest(manifestPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.modify(m)
+		if err := writeManifest(manifestPath, m); err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- run(context.TODO(), log.Discard())
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("%s: got %v, want %v", tt.name, err, nil)
+				continue
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: resume did not finish", tt.name)
+		}
+
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s: got %s, want %s", tt.name, got, expected)
+		}
+
+		m, err = readManifest(manifestPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.Complete || m.SHA256 == "" {
+			t.Errorf("%s: got complete %v sha256 %q, want finalised manifest", tt.name, m.Complete, m.SHA256)
+		}
+	}
+}
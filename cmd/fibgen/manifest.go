@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// manifest records generation progress and checksums of the output.
+// It is rewritten after every committed chunk, so interrupted generation can be resumed.
+type manifest struct {
+	Format     string `json:"format"`
+	From       uint64 `json:"from"`
+	To         uint64 `json:"to"`
+	ChunkSize  uint64 `json:"chunk_size"`
+	ChunkBytes uint64 `json:"chunk_bytes"`
+
+	Next     uint64     `json:"next"`             // next term to be generated
+	Size     int64      `json:"size"`             // bytes of the output committed so far
+	Chunks   []chunkSum `json:"chunks"`           // checksums of committed chunks
+	SHA256   string     `json:"sha256,omitempty"` // checksum of the whole output, set once complete
+	Complete bool       `json:"complete"`
+}
+
+// chunkSum represents checksum of a single committed chunk.
+type chunkSum struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Offset int64  `json:"offset"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// matches reports whether m describes generation of the same output as o.
+func (m *manifest) matches(o *manifest) bool {
+	return m.Format == o.Format && m.From == o.From && m.To == o.To && m.ChunkSize == o.ChunkSize &&
+		m.ChunkBytes == o.ChunkBytes
+}
+
+// readManifest reads manifest stored at path.
+func readManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrapf(err, "decoding manifest %s", path)
+	}
+
+	return &m, nil
+}
+
+// writeManifest atomically replaces manifest stored at path with m.
+func writeManifest(path string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding manifest")
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Wrapf(err, "writing manifest %s", tmp)
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// component is a part of the service which has to be stopped on shutdown.
+type component struct {
+	name    string
+	timeout time.Duration                   // deadline of stopping the component
+	stop    func(ctx context.Context) error // stop stops the component, ctx is done once timeout elapses
+}
+
+// lifecycle stops registered components in reverse order of their registration, so components are
+// stopped before ones they depend on. Each component is given its own deadline to stop.
+type lifecycle struct {
+	logger     log.Logger
+	components []component
+}
+
+// register registers component called name stopped by stop within timeout.
+// Components must be registered once they are started, dependencies first.
+func (l *lifecycle) register(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	l.components = append(l.components, component{name: name, timeout: timeout, stop: stop})
+}
+
+// stop stops registered components in reverse order of registration and forgets them.
+// Component failing or missing its deadline does not prevent others from being stopped, all failures are reported.
+func (l *lifecycle) stop() error {
+	var errs []error
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := l.components[i]
+
+		start := time.Now()
+		if err := stopComponent(c); err != nil {
+			l.logger.WithError(err).WithFields(log.Fields{"component": c.name}).Error("component did not stop cleanly")
+			errs = append(errs, errors.Wrapf(err, "stopping %s", c.name))
+			continue
+		}
+
+		l.logger.WithFields(log.Fields{
+			"component": c.name,
+			"duration":  time.Since(start).String(),
+		}).Info("component stopped")
+	}
+	l.components = nil
+
+	return errors.Join(errs...)
+}
+
+// stopComponent stops c giving up once its deadline is exceeded, even if c does not respect it.
+func stopComponent(c component) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+func TestLifecycleStop(t *testing.T) {
+	lc := lifecycle{logger: log.Discard()}
+
+	var stopped []string
+	stop := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			stopped = append(stopped, name)
+			return err
+		}
+	}
+
+	errFlush := errors.New("flush failed")
+
+	lc.register("store", time.Second, stop("store", nil))
+	lc.register("tracing", time.Second, stop("tracing", errFlush))
+	lc.register("stuck", 10*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(time.Second) // ignores its deadline
+		return nil
+	})
+	lc.register("http", time.Second, stop("http", nil))
+
+	err := lc.stop()
+	if !errors.Is(err, errFlush) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v and %v", err, errFlush, context.DeadlineExceeded)
+	}
+
+	expected := []string{"http", "tracing", "store"}
+	if len(stopped) != len(expected) {
+		t.Fatalf("got %v, want %v", stopped, expected)
+	}
+	for i := range expected {
+		if stopped[i] != expected[i] {
+			t.Errorf("#%d got %v, want %v", i, stopped[i], expected[i])
+		}
+	}
+
+	// components are stopped once
+	if err := lc.stop(); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+}
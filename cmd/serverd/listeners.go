@@ -0,0 +1,243 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// Environment variables of systemd socket activation protocol, see sd_listen_fds(3).
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+
+	// envUpgradePPID holds pid of the process handing its files over on upgrade. It is used instead of
+	// LISTEN_PID, as pid of the new process is not known before it is started.
+	envUpgradePPID = "SERVERD_UPGRADE_PPID"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation protocol.
+const listenFDsStart = 3
+
+// Names of inherited files.
+const (
+	fileHTTP    = "http"    // public listener
+	fileAdmin   = "admin"   // admin listener
+	fileReady   = "ready"   // written to by the new process once it is ready to take over on upgrade
+	fileHandoff = "handoff" // final counter state written by the process handing over on upgrade
+)
+
+// inheritedFiles returns files passed to the process by systemd socket activation or by the process
+// handing over on upgrade by their names. Files not named after a known name are named by their order:
+// the first one is public listener and the second one is admin listener. Nil is returned when no files
+// were passed to this process. Protocol environment variables are unset, so they are not inherited further.
+func inheritedFiles() (map[string]*os.File, error) {
+	pid, ppid := os.Getenv(envListenPID), os.Getenv(envUpgradePPID)
+	fds, names := os.Getenv(envListenFDs), os.Getenv(envListenFDNames)
+	for _, env := range []string{envListenPID, envListenFDs, envListenFDNames, envUpgradePPID} {
+		os.Unsetenv(env)
+	}
+
+	switch {
+	case pid != "":
+		if pid != strconv.Itoa(os.Getpid()) {
+			return nil, nil
+		}
+	case ppid != "":
+		if ppid != strconv.Itoa(os.Getppid()) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, errors.Newf("%s %q is not a number of file descriptors", envListenFDs, fds)
+	}
+
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+
+	files := make(map[string]*os.File, n)
+	for i := 0; i < n; i++ {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := ""
+		if i < len(fdNames) {
+			name = fdNames[i]
+		}
+		switch name {
+		case fileHTTP, fileAdmin, fileReady, fileHandoff:
+		default:
+			// systemd names sockets after their unit unless FileDescriptorName= is set
+			name = []string{fileHTTP, fileAdmin}[i%2]
+			if i > 1 {
+				name = "fd" + strconv.Itoa(fd)
+			}
+		}
+
+		files[name] = os.NewFile(uintptr(fd), name)
+	}
+
+	return files, nil
+}
+
+// upgradeListen returns listener of file inherited as name, it listens on TCP address addr when there is none.
+func upgradeListen(files map[string]*os.File, name, addr string) (*upgradeListener, error) {
+	f, ok := files[name]
+	if !ok {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listening on %s", addr)
+		}
+		return newUpgradeListener(ln)
+	}
+
+	// listener holds a duplicate of the file descriptor
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "using inherited %s listener", name)
+	}
+	return newUpgradeListener(ln)
+}
+
+// acceptTimeout is how long connections accepted before upgrade are given to send their first request,
+// server shutdown drops connections which did not.
+const acceptTimeout = time.Second
+
+// upgradeListener is a TCP listener which is able to stop accepting connections without being closed,
+// leaving them queued for the process taking over on upgrade. Accepted connections which did not send their
+// first request yet are tracked by trackConn, servers must be served by serve, so it is set as their hook.
+type upgradeListener struct {
+	*net.TCPListener
+
+	closed    chan struct{} // closed once the listener is closed
+	closeOnce sync.Once
+	stopped   chan struct{} // closed once Accept notices accepting was stopped
+	stopOnce  sync.Once
+
+	mu      sync.Mutex
+	pending int                   // accepted connections which did not send a request yet
+	fresh   map[net.Conn]struct{} // connections in http.StateNew as seen by http.Server
+	idle    chan struct{}         // closed once there are no pending connections, nil when there are none
+}
+
+// newUpgradeListener wraps ln, which must be a TCP listener.
+func newUpgradeListener(ln net.Listener) (*upgradeListener, error) {
+	tcp, ok := ln.(*net.TCPListener)
+	if !ok {
+		return nil, errors.Newf("%s is not a TCP listener", ln.Addr())
+	}
+	return &upgradeListener{
+		TCPListener: tcp,
+		closed:      make(chan struct{}),
+		stopped:     make(chan struct{}),
+		fresh:       make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Accept waits for and returns the next connection, once accepting is stopped it blocks until l is closed.
+func (l *upgradeListener) Accept() (net.Conn, error) {
+	conn, err := l.TCPListener.Accept()
+	if err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			l.stopOnce.Do(func() { close(l.stopped) })
+			<-l.closed
+			return nil, net.ErrClosed
+		}
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.pending++
+	if l.idle == nil {
+		l.idle = make(chan struct{})
+	}
+	l.mu.Unlock()
+
+	return conn, nil
+}
+
+// Close closes the listener.
+func (l *upgradeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.TCPListener.Close()
+}
+
+// serve serves server on l tracking connections it accepts, over TLS when server has TLS config.
+// Certificates are provided by the TLS config, so they can be reloaded.
+func (l *upgradeListener) serve(server *http.Server) error {
+	server.ConnState = l.trackConn
+	if server.TLSConfig != nil {
+		return server.ServeTLS(l, "", "")
+	}
+	return server.Serve(l)
+}
+
+// trackConn tracks conn changing its state to state until it sends its first request.
+func (l *upgradeListener) trackConn(conn net.Conn, state http.ConnState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state == http.StateNew {
+		l.fresh[conn] = struct{}{}
+		return
+	}
+
+	if _, ok := l.fresh[conn]; !ok {
+		return
+	}
+	delete(l.fresh, conn)
+
+	l.pending--
+	if l.pending == 0 {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// stop stops accepting connections and waits until accepted ones send their first request or timeout elapses.
+func (l *upgradeListener) stop(timeout time.Duration) error {
+	// pending Accept is interrupted by the deadline
+	if err := l.SetDeadline(time.Unix(1, 0)); err != nil {
+		return errors.Wrap(err, "stopping accepting connections")
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// connections are accepted one at a time, all of them are counted once Accept notices it was stopped
+	select {
+	case <-l.stopped:
+	case <-timer.C:
+		return errors.New("accepting connections did not stop in time")
+	}
+
+	l.mu.Lock()
+	idle := l.idle
+	l.mu.Unlock()
+	if idle == nil {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-timer.C:
+		return errors.New("connections did not send a request in time")
+	}
+}
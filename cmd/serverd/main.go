@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,18 +15,21 @@ import (
 	"github.com/deividaspetraitis/fibonacci/errors"
 	ihttp "github.com/deividaspetraitis/fibonacci/http"
 	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/store"
+	"github.com/deividaspetraitis/fibonacci/tracing"
 )
 
-var shutdowntimeout = time.Duration(5) * time.Second
-
 // program flags
 var (
-	cfgPath string
+	cfgPath     string
+	printConfig bool
 )
 
 // initialise program state
 func init() {
-	flag.StringVar(&cfgPath, "config", os.Getenv("config"), "PATH to .env configuration file")
+	flag.StringVar(&cfgPath, "config", os.Getenv("config"), "PATH to optional configuration file: .env, .yaml, .toml or .json")
+	flag.BoolVar(&printConfig, "print-config", false, "print effective configuration with secrets redacted and exit")
+	config.RegisterFlags(flag.CommandLine)
 }
 
 // main program entry point.
@@ -34,17 +38,40 @@ func main() {
 
 	logger := log.Default()
 
-	cfg, err := config.New(cfgPath)
+	cfg, err := config.New(cfgPath, flag.CommandLine)
+	if err != nil {
+		logger.WithError(err).Fatal("parsing configuration")
+	}
+
+	if printConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			logger.WithError(err).Fatal("printing configuration")
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logger.WithError(err).Fatal("invalid configuration")
+	}
+
+	if printConfig {
+		return
+	}
+
+	logOutput, err := log.Configure(logger, cfg.Log)
 	if err != nil {
-		logger.WithError(err).Fatal("parsing configuration file")
+		logger.WithError(err).Fatal("configuring logger")
 	}
 
+	// Fatal would exit skipping deferred calls, log output is closed explicitly so rotated files get compressed.
 	if err := run(cfg, logger); err != nil {
-		logger.WithError(err).Fatal("unable to start service")
+		logger.WithError(err).Error("unable to start service")
+		logOutput.Close()
+		os.Exit(1)
 	}
+	logOutput.Close()
 }
 
-func run(cfg *config.Config, logger log.Logger) error {
+func run(cfg *config.Config, logger log.Logger) (err error) {
 	// Make a channel to listen for errors coming from the listener. Use a
 	// buffered channel so the goroutine can exit if we don't collect this error.
 	serverErrors := make(chan error, 1)
@@ -54,54 +81,373 @@ func run(cfg *config.Config, logger log.Logger) error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
+	// Make a channel to listen for a hangup signal asking to reload configuration.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// Make a channel to listen for a signal asking to hand listeners over to a new process.
+	usr2 := make(chan os.Signal, 1)
+	signal.Notify(usr2, syscall.SIGUSR2)
+
+	// Components are registered once started and stopped in reverse order no matter how run returns,
+	// so the final counter position is persisted once nothing is able to move the counter anymore.
+	lc := lifecycle{logger: logger}
+	defer func() {
+		if stopErr := lc.stop(); stopErr != nil {
+			err = errors.Join(err, errors.Wrap(stopErr, "could not stop service gracefully"))
+		}
+	}()
+
+	// =========================================================================
+	// Start tracing
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "serverd", cfg.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "setting up tracing")
+	}
+	lc.register("tracing", cfg.HTTP.ShutdownTimeout, shutdownTracing)
+
+	// =========================================================================
+	// Open listeners
+
+	// Listeners are inherited from systemd socket activation or from the previous process on upgrade.
+	files, err := inheritedFiles()
+	if err != nil {
+		return err
+	}
+
+	// Servers close listeners they serve once they are shut down, until then listeners are closed on return.
+	// Inherited files are either used or not needed by then.
+	var apiListener, adminListener *upgradeListener
+	serving := false
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+		if serving {
+			return
+		}
+		for _, ln := range []*upgradeListener{apiListener, adminListener} {
+			if ln != nil {
+				ln.Close()
+			}
+		}
+	}()
+
+	apiListener, err = upgradeListen(files, fileHTTP, cfg.HTTP.Address)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Admin.Address != "" {
+		adminListener, err = upgradeListen(files, fileAdmin, cfg.Admin.Address)
+		if err != nil {
+			return err
+		}
+	} else if f, ok := files[fileAdmin]; ok {
+		f.Close()
+	}
+
 	// =========================================================================
 	// Construct services
 
 	app := fibonacci.Fibonacci{}
+	if err := app.SetConfig(*cfg.Sequence); err != nil {
+		return errors.Wrap(err, "configuring sequence")
+	}
 
-	// =========================================================================
-	// Start HTTP server
+	metrics := ihttp.NewMetrics(&app)
+
+	health := ihttp.NewHealth()
+	health.RegisterLiveness("counter", ihttp.CheckerFunc(app.Verify))
+
+	auths, err := authenticators(cfg.HTTP.Auth)
+	if err != nil {
+		return err
+	}
+
+	// Authentication is disabled unless API keys or JWKS file is configured.
+	var auth ihttp.Authenticator
+	if len(auths) > 0 {
+		auth = auths
+	}
+
+	web := ihttp.NewApp(shutdown, logger)
+
+	// Reloads replace configuration printed by admin listener.
+	reloads := &reloader{
+		path:     cfgPath,
+		flags:    flag.CommandLine,
+		logger:   logger,
+		app:      web,
+		sequence: &app,
+		current:  cfg,
+	}
+
+	// Profile capture is disabled unless profile directory is configured.
+	var profiles *ihttp.Profiles
+	if cfg.Admin.ProfileDir != "" {
+		profiles, err = ihttp.NewProfiles(cfg.Admin.ProfileDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	adminAuth := adminAuthenticator(cfg.Admin, auths)
+	if adminAuth == nil && adminListener != nil {
+		logger.Warn("admin http server authentication is disabled, do not expose it")
+	}
+
+	admin := http.Server{
+		Handler:           ihttp.Admin(&app, metrics, health, reloads.print, profiles, adminAuth, logger),
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+	}
 
 	api := http.Server{
-		Addr:    cfg.HTTP.Address,
-		Handler: ihttp.API(shutdown, cfg.HTTP, &app, logger),
+		Handler:           ihttp.API(web, cfg.HTTP, &app, metrics, health, auth),
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:    cfg.HTTP.MaxHeaderBytes,
+	}
+
+	if cfg.HTTP.TLS.Enabled() {
+		certs, err := ihttp.NewTLS(cfg.HTTP.TLS, logger)
+		if err != nil {
+			return errors.Wrap(err, "loading TLS files")
+		}
+		api.TLSConfig = certs.Config()
 	}
 
+	// =========================================================================
+	// Restore counter state
+
+	// On upgrade the previous process hands its counter state over once it stops, nothing is served meanwhile.
+	handedOver, err := takeOver(files)
+	if err != nil {
+		return err
+	}
+
+	// Write end of handoff pipe is set once a new process is ready to take over on upgrade.
+	var handoff *os.File
+
+	// Counter state is checkpointed by integrity checks while it is persisted.
+	var integrity ihttp.Checker = ihttp.CheckerFunc(app.Verify)
+
+	if cfg.Store.Path != "" {
+		state := store.NewFile(cfg.Store.Path)
+		// counter state not being able to be persisted would be lost on restart
+		health.RegisterReadiness("store", state)
+		if handedOver == nil {
+			if err := restore(&app, state, logger); err != nil {
+				return err
+			}
+		}
+		lc.register("store", storeTimeout, func(ctx context.Context) error {
+			// the new process persists handed over state by itself
+			if handoff != nil {
+				return nil
+			}
+			return persist(ctx, &app, state, logger)
+		})
+		integrity = &checkpoint{app: &app, state: state}
+	} else {
+		logger.Warn("counter state persistence is disabled, counter starts from the lowest term on restart")
+	}
+
+	if handedOver != nil {
+		seek(&app, *handedOver, "handed over", logger)
+	}
+
+	// Checkpoints verify persisted state against the one they saved, so the first one is saved right away.
+	if err := integrity.Check(context.Background()); err != nil {
+		return errors.Wrap(err, "checking counter integrity")
+	}
+
+	lc.register("handoff", storeTimeout, func(ctx context.Context) error {
+		if handoff == nil {
+			return nil
+		}
+		return handOver(ctx, &app, handoff)
+	})
+
+	// =========================================================================
+	// Start integrity verification
+
+	verifyCtx, stopVerify := context.WithCancel(log.NewContext(context.Background(), logger))
+	verified := make(chan struct{})
 	go func() {
-		logger.Printf("http server listening on %s", cfg.HTTP.Address)
-		serverErrors <- api.ListenAndServe()
+		defer close(verified)
+		web.VerifyIntegrity(verifyCtx, cfg.Integrity.Interval, integrity)
+	}()
+	// Checkpoint being saved must not race with the final counter state being persisted or handed over.
+	lc.register("integrity", cfg.HTTP.ShutdownTimeout, func(ctx context.Context) error {
+		stopVerify()
+		select {
+		case <-verified:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	// =========================================================================
+	// Start Admin HTTP server
+
+	if adminListener != nil {
+		go func() {
+			logger.Printf("admin http server listening on %s", adminListener.Addr())
+			serverErrors <- adminListener.serve(&admin)
+		}()
+
+		// Admin listener is the last one to report the drain, profiles being captured are cut short.
+		lc.register("admin http server", cfg.HTTP.ShutdownTimeout, shutdownServer(&admin))
+	} else {
+		logger.Printf("admin http server is disabled")
+	}
+
+	// =========================================================================
+	// Start HTTP server
+
+	go func() {
+		if api.TLSConfig != nil {
+			logger.Printf("https server listening on %s", apiListener.Addr())
+		} else {
+			logger.Printf("http server listening on %s", apiListener.Addr())
+		}
+		serverErrors <- apiListener.serve(&api)
 	}()
+	serving = true
+
+	// WebSocket connections are hijacked, they are closed once the server stops accepting new ones.
+	lc.register("websockets", cfg.HTTP.ShutdownTimeout, web.Shutdown)
+	lc.register("http server", cfg.HTTP.ShutdownTimeout, shutdownServer(&api))
+
+	// =========================================================================
+	// Start configuration reloads
+
+	reloadCtx, stopReloads := context.WithCancel(context.Background())
+	go reloads.watch(reloadCtx, hup)
+	lc.register("reloads", cfg.HTTP.ShutdownTimeout, func(context.Context) error {
+		stopReloads()
+		return nil
+	})
+
+	health.SetReady(true)
+
+	// Stop receiving new traffic before anything else, admin listener keeps reporting it during the drain.
+	// Load balancers notice failing probe only once they poll it, so listeners keep serving for a while.
+	// On upgrade the new process serves the same listeners already, so there is nothing to wait for.
+	lc.register("readiness", cfg.HTTP.DrainDelay+cfg.HTTP.ShutdownTimeout, func(ctx context.Context) error {
+		health.SetReady(false)
+		if handoff != nil {
+			return nil
+		}
+
+		select {
+		case <-time.After(cfg.HTTP.DrainDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	// systemd tracks the process which took over on upgrade as the main one.
+	if err := notify("READY=1\nMAINPID=" + strconv.Itoa(os.Getpid())); err != nil {
+		logger.WithError(err).Warn("unable to notify service manager")
+	}
 
 	// ========================================================================
 	// Shutdown
 
 	// Blocking main and waiting for shutdown.
-	select {
-	case err := <-serverErrors:
-		return errors.Wrap(err, "server error")
+	for {
+		select {
+		case err := <-serverErrors:
+			return errors.Wrap(err, "server error")
+
+		case sig := <-usr2:
+			logger.Printf("upgrade caused by %v", sig)
+
+			w, err := upgrade(apiListener, adminListener)
+			if err != nil {
+				logger.WithError(err).Error("unable to upgrade, keeping the current process")
+				continue
+			}
+			handoff = w
+
+			// Server shutdown drops connections which did not send a request yet, new ones are left queued for the new process.
+			for _, ln := range []*upgradeListener{apiListener, adminListener} {
+				if ln == nil {
+					continue
+				}
+				if err := ln.stop(acceptTimeout); err != nil {
+					logger.WithError(err).Warn("connections accepted before upgrade might be dropped")
+				}
+			}
+
+			logger.Print("new process is ready to take over, http server start shutdown")
+			return nil
 
-	case sig := <-shutdown:
-		logger.Printf("http server start shutdown caused by %v", sig)
+		case sig := <-shutdown:
+			logger.Printf("http server start shutdown caused by %v", sig)
 
-		// Give outstanding requests a deadline for completion.
-		ctx, cancel := context.WithTimeout(context.Background(), shutdowntimeout)
-		defer cancel()
+			// Log the status of this shutdown.
+			if sig == syscall.SIGSTOP {
+				return errors.New("integrity issue caused shutdown")
+			}
+			return nil
+		}
+	}
+}
 
+// shutdownServer returns function gracefully shutting down server, it is closed once the deadline is exceeded.
+func shutdownServer(server *http.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
 		// Asking listener to shutdown and load shed.
-		err := api.Shutdown(ctx)
+		if err := server.Shutdown(ctx); err != nil {
+			server.Close()
+			return errors.Wrap(err, "graceful shutdown did not complete")
+		}
+		return nil
+	}
+}
+
+// authenticators constructs authenticators configured by cfg.
+func authenticators(cfg ihttp.AuthConfig) (ihttp.Authenticators, error) {
+	var auths ihttp.Authenticators
+
+	if cfg.KeysFile != "" {
+		keys, err := ihttp.NewAPIKeys(cfg.KeysFile)
 		if err != nil {
-			logger.WithError(err).Error("graceful shutdown did not complete")
-			api.Close()
+			return nil, errors.Wrap(err, "loading API keys")
 		}
+		auths = append(auths, keys)
+	}
 
-		// Log the status of this shutdown.
-		switch {
-		case sig == syscall.SIGSTOP:
-			return errors.New("integrity issue caused shutdown")
-		case err != nil:
-			return errors.Wrap(err, "could not stop server gracefully")
+	if cfg.JWT.JWKSFile != "" {
+		jwt, err := ihttp.NewJWT(cfg.JWT)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading JWKS")
 		}
+		auths = append(auths, jwt)
 	}
 
-	return nil
+	return auths, nil
+}
+
+// adminAuthenticator constructs authenticator of admin listener accepting admin token configured by cfg and
+// credentials accepted by auths granting admin scope. Nil is returned when there are none, disabling authentication.
+func adminAuthenticator(cfg *ihttp.AdminConfig, auths ihttp.Authenticators) ihttp.Authenticator {
+	var admin ihttp.Authenticators
+	if cfg.Token != "" {
+		admin = append(admin, ihttp.NewBearerToken("admin", cfg.Token, ihttp.ScopeAdmin))
+	}
+	admin = append(admin, auths...)
+
+	if len(admin) == 0 {
+		return nil
+	}
+	return admin
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/config"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	ihttp "github.com/deividaspetraitis/fibonacci/http"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// reloadInterval is interval configuration file is checked for changes at.
+const reloadInterval = time.Second
+
+// reloadable lists prefixes of settings keys applied at runtime, changes of other settings require restart.
+var reloadable = []string{"LOG_LEVEL", "HTTP_RATELIMIT_", "SEQUENCE_"}
+
+// reloader reloads configuration applying settings safe to change at runtime to the running service.
+// It is safe to use reloader concurrently.
+type reloader struct {
+	path     string        // configuration file, empty when there is none
+	flags    *flag.FlagSet // flags overriding configuration
+	logger   log.Logger
+	app      *ihttp.App
+	sequence *fibonacci.Fibonacci
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+// print writes the current configuration to w with secrets redacted.
+func (r *reloader) print(w io.Writer) error {
+	// configuration is replaced by reloads, never modified
+	r.mu.Lock()
+	cfg := r.current
+	r.mu.Unlock()
+
+	return config.Print(w, cfg)
+}
+
+// reload loads configuration again and applies it: log level, sequence bounds and overflow policy are
+// changed, rate limits are replaced and authentication credentials reloaded. Invalid configuration is rejected keeping the current one.
+// Changed settings are logged, ones requiring restart with a warning.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.New(r.path, r.flags)
+	if err != nil {
+		return errors.Wrap(err, "parsing configuration")
+	}
+	if err := cfg.Validate(); err != nil {
+		return errors.Wrap(err, "invalid configuration")
+	}
+
+	level, err := log.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return errors.Wrap(err, "invalid configuration")
+	}
+
+	// Credentials loaded from their files are only staged, so when the sequence rejects its settings
+	// nothing is changed, the rest is applied once nothing is able to fail anymore.
+	applyHTTP, err := r.app.StageReload(cfg.HTTP)
+	if err != nil {
+		return err
+	}
+	if err := r.sequence.SetConfig(*cfg.Sequence); err != nil {
+		return errors.Wrap(err, "invalid configuration")
+	}
+	applyHTTP()
+	// access log shares the level
+	r.logger.SetLevel(level)
+
+	for _, change := range config.Diff(r.current, cfg) {
+		entry := r.logger.WithFields(log.Fields{
+			"key": change.Key,
+			"old": change.Old,
+			"new": change.New,
+		})
+
+		if isReloadable(change.Key) {
+			entry.Info("configuration setting changed")
+		} else {
+			entry.Warn("configuration setting changed, restart is required to apply it")
+		}
+	}
+
+	r.current = cfg
+	return nil
+}
+
+// watch reloads configuration on every signal received from hup and once configuration file changes until ctx is done.
+func (r *reloader) watch(ctx context.Context, hup <-chan os.Signal) {
+	// without configuration file there is nothing to watch, only signals trigger reloads
+	var tick <-chan time.Time
+	if r.path != "" {
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(r.path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case sig := <-hup:
+			r.logger.Printf("configuration reload caused by %v", sig)
+
+		case <-tick:
+			// missing file is considered unchanged, it might be just being replaced
+			info, err := os.Stat(r.path)
+			if err != nil || (info.ModTime().Equal(modTime) && info.Size() == size) {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+			r.logger.Printf("configuration reload caused by change of %s", r.path)
+		}
+
+		if err := r.reload(); err != nil {
+			r.logger.WithError(err).Error("unable to reload configuration, keeping the current one")
+			continue
+		}
+		r.logger.Info("configuration reloaded")
+	}
+}
+
+// isReloadable reports whether setting identified by key is applied at runtime.
+func isReloadable(key string) bool {
+	for _, prefix := range reloadable {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
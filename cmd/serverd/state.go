@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/store"
+)
+
+// storeTimeout is deadline of persisting the final counter state on shutdown.
+const storeTimeout = 5 * time.Second
+
+// restore moves app counter to the position persisted in state.
+// Counter starts from the lowest term when nothing was persisted yet or the position is out of the bounds.
+func restore(app *fibonacci.Fibonacci, state *store.File, logger log.Logger) error {
+	s, err := state.Load()
+	switch {
+	case errors.Is(err, store.ErrStateNotFound):
+		logger.Info("no counter state persisted yet, counter starts from the lowest term")
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "restoring counter state")
+	}
+
+	seek(app, s, "persisted", logger)
+	return nil
+}
+
+// seek moves app counter to the position of state s coming from source.
+// Counter starts from the lowest term when the position is out of the bounds.
+func seek(app *fibonacci.Fibonacci, s store.State, source string, logger log.Logger) {
+	if _, err := app.SeekFibonacciNumber(context.Background(), s.Position); err != nil {
+		logger.WithError(err).WithFields(log.Fields{"position": s.Position}).Warn(source + " counter position is out of the bounds, counter starts from the lowest term")
+		return
+	}
+
+	logger.WithFields(log.Fields{"position": s.Position, "saved_at": s.SavedAt}).Info(source + " counter state restored")
+}
+
+// persist persists app counter position to state, corrupted counter is never persisted.
+func persist(ctx context.Context, app *fibonacci.Fibonacci, state *store.File, logger log.Logger) error {
+	if err := app.Verify(ctx); err != nil {
+		return errors.Wrap(err, "refusing to persist counter state")
+	}
+
+	position := app.Position()
+	if err := state.Save(store.State{Position: position, SavedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+
+	logger.WithFields(log.Fields{"position": position}).Info("counter state persisted")
+	return nil
+}
+
+// checkpoint persists app counter state on integrity checks the counter moved since the previous one,
+// so little progress is lost on crash while the state file is not rewritten while the counter stands still.
+// It verifies the persisted position is still the one it saved, the state file is never changed behind
+// the process back unless, for example, another instance shares it.
+type checkpoint struct {
+	app   *fibonacci.Fibonacci
+	state *store.File
+	saved *int // position persisted by the previous checkpoint, nil until the first one
+}
+
+// Check implements ihttp.Checker, corrupted counter is never persisted.
+func (c *checkpoint) Check(ctx context.Context) error {
+	if err := c.app.Verify(ctx); err != nil {
+		return err
+	}
+
+	position := c.app.Position()
+
+	s, err := c.state.Load()
+	switch {
+	case errors.Is(err, store.ErrStateNotFound) && c.saved == nil:
+		// nothing persisted yet
+	case err != nil:
+		return errors.Wrap(err, "loading persisted counter state")
+	case c.saved != nil && s.Position != *c.saved:
+		return errors.Wrapf(fibonacci.ErrCounterCorrupted, "persisted position %d does not match %d saved by the previous checkpoint", s.Position, *c.saved)
+	case s.Position == position:
+		c.saved = &position
+		return nil
+	}
+
+	if err := c.state.Save(store.State{Position: position, SavedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+	c.saved = &position
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	ihttp "github.com/deividaspetraitis/fibonacci/http"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/store"
+)
+
+func TestCheckpoint(t *testing.T) {
+	app := fibonacci.Fibonacci{}
+	if _, err := app.SeekFibonacciNumber(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	state := store.NewFile(filepath.Join(t.TempDir(), "state.json"))
+	c := &checkpoint{app: &app, state: state}
+
+	var testcases = []struct {
+		position int
+		saved    bool
+	}{
+		{position: 10, saved: true},
+		{position: 11, saved: true},
+		{position: 11, saved: false}, // counter stands still, state file is not rewritten
+	}
+
+	var savedAt time.Time
+	for i, tt := range testcases {
+		if _, err := app.SeekFibonacciNumber(context.Background(), tt.position); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Check(context.Background()); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+
+		s, err := state.Load()
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if s.Position != tt.position {
+			t.Errorf("#%d position got %v, want %v", i, s.Position, tt.position)
+		}
+		if got := !s.SavedAt.Equal(savedAt); got != tt.saved {
+			t.Errorf("#%d saved got %v, want %v", i, got, tt.saved)
+		}
+		savedAt = s.SavedAt
+	}
+
+	// another instance sharing the state file persists its own position
+	if err := state.Save(store.State{Position: 30, SavedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	web := ihttp.NewApp(shutdown, log.Discard())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	web.VerifyIntegrity(ctx, time.Millisecond, c)
+
+	select {
+	case sig := <-shutdown:
+		if sig != syscall.SIGSTOP {
+			t.Errorf("got %v, want %v", sig, syscall.SIGSTOP)
+		}
+	default:
+		t.Errorf("got no shutdown signal, want %v", syscall.SIGSTOP)
+	}
+
+	if err := c.Check(context.Background()); !errors.Is(err, fibonacci.ErrCounterCorrupted) {
+		t.Errorf("got %v, want %v", err, fibonacci.ErrCounterCorrupted)
+	}
+}
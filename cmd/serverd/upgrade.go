@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/store"
+)
+
+// upgradeTimeout is how long the new process is given to get ready to take over on upgrade.
+const upgradeTimeout = 30 * time.Second
+
+// upgrade starts a new process of the current executable with the same arguments handing it api and admin
+// listeners, admin may be nil. Connections keep being queued by the listeners while the current process stops
+// and are accepted by the new one once it takes over.
+// upgrade returns once the new process is ready to take over with write end of handoff pipe, counter state
+// must be handed over through it by handOver once nothing is able to move the counter anymore.
+// The new process is killed when it fails to get ready in time.
+func upgrade(api, admin *upgradeListener) (*os.File, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "locating executable")
+	}
+
+	var files []*os.File
+	var names []string
+	defer func() {
+		// the new process holds its own copies
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, l := range []struct {
+		name string
+		ln   *upgradeListener
+	}{{fileHTTP, api}, {fileAdmin, admin}} {
+		if l.ln == nil {
+			continue
+		}
+
+		f, err := l.ln.File()
+		if err != nil {
+			return nil, errors.Wrapf(err, "handing over %s listener", l.name)
+		}
+		files, names = append(files, f), append(names, l.name)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating ready pipe")
+	}
+	defer readyR.Close()
+	files, names = append(files, readyW), append(names, fileReady)
+
+	handoffR, handoffW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating handoff pipe")
+	}
+	files, names = append(files, handoffR), append(names, fileHandoff)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envListenFDs+"="+strconv.Itoa(len(files)),
+		envListenFDNames+"="+strings.Join(names, ":"),
+		envUpgradePPID+"="+strconv.Itoa(os.Getpid()),
+	)
+
+	if err := cmd.Start(); err != nil {
+		handoffW.Close()
+		return nil, errors.Wrapf(err, "starting %s", exe)
+	}
+
+	// Closing parent copies of the child ends makes reading ready pipe fail once the new process exits.
+	for _, f := range files {
+		f.Close()
+	}
+	files = nil
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyR.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	timer := time.NewTimer(upgradeTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case <-timer.C:
+		err = errors.New("timed out")
+	}
+	if err != nil {
+		handoffW.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, errors.Wrapf(err, "new process %d did not get ready to take over", cmd.Process.Pid)
+	}
+
+	// the new process outlives this one, it is released rather than waited for
+	cmd.Process.Release()
+
+	return handoffW, nil
+}
+
+// handOver writes app counter state to handoff pipe w for the new process to take over and closes it.
+// Corrupted counter is never handed over, the new process falls back to the persisted state then.
+func handOver(ctx context.Context, app *fibonacci.Fibonacci, w *os.File) error {
+	defer w.Close()
+
+	if err := app.Verify(ctx); err != nil {
+		return errors.Wrap(err, "refusing to hand over counter state")
+	}
+
+	state := store.State{Position: app.Position(), SavedAt: time.Now().UTC()}
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		return errors.Wrap(err, "handing over counter state")
+	}
+	return nil
+}
+
+// takeOver tells the process which started this one on upgrade that it is ready to take over and waits
+// until the process stops returning counter state it handed over. Nil state is returned when this process
+// was not started by upgrade or nothing was handed over.
+func takeOver(files map[string]*os.File) (*store.State, error) {
+	ready, ok := files[fileReady]
+	if !ok {
+		return nil, nil
+	}
+	handoff, ok := files[fileHandoff]
+	if !ok {
+		ready.Close()
+		return nil, errors.Newf("%s file was not inherited", fileHandoff)
+	}
+	defer handoff.Close()
+
+	_, err := ready.Write([]byte{1})
+	ready.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "reporting readiness to take over")
+	}
+
+	// handoff pipe is closed once the previous process stops, even if it crashes
+	data, err := io.ReadAll(handoff)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading handed over counter state")
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var state store.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrap(err, "parsing handed over counter state")
+	}
+	return &state, nil
+}
+
+// notify sends state to systemd service manager, see sd_notify(3).
+// It does nothing unless the process is run by systemd with notification socket.
+func notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return errors.Wrap(err, "connecting to notification socket")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return errors.Wrap(err, "notifying service manager")
+	}
+	return nil
+}
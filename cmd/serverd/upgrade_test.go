@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/store"
+)
+
+func TestHandOver(t *testing.T) {
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readyR.Close()
+
+	handoffR, handoffW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		state *store.State
+		err   error
+	}
+	took := make(chan result, 1)
+	go func() {
+		state, err := takeOver(map[string]*os.File{fileReady: readyW, fileHandoff: handoffR})
+		took <- result{state, err}
+	}()
+
+	if _, err := readyR.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	app := fibonacci.Fibonacci{}
+	if _, err := app.SeekFibonacciNumber(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := handOver(context.Background(), &app, handoffW); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	r := <-took
+	if r.err != nil {
+		t.Fatalf("got %v, want %v", r.err, nil)
+	}
+	if r.state == nil || r.state.Position != 10 {
+		t.Errorf("got %v, want position %v", r.state, 10)
+	}
+
+	// not started by upgrade
+	state, err := takeOver(nil)
+	if state != nil || err != nil {
+		t.Errorf("got %v, %v, want %v, %v", state, err, nil, nil)
+	}
+}
+
+func TestUpgradeListenerStop(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := newUpgradeListener(tcp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+	go ln.serve(&server)
+	defer server.Close()
+
+	// accepted connection which did not send its request yet holds stop back
+	accepted, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer accepted.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- ln.stop(time.Second)
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("got %v, want stop to wait for the request", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	fmt.Fprint(accepted, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	if err := <-stopped; err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(accepted), nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("got %v, want %v", res.StatusCode, http.StatusNoContent)
+	}
+
+	// connections made once accepting is stopped are left queued
+	queued, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queued.Close()
+
+	fmt.Fprint(queued, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	queued.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := queued.Read(make([]byte, 1)); !os.IsTimeout(err) {
+		t.Errorf("got %v, want timeout", err)
+	}
+}
+
+func TestUpgradeListenerStopServed(t *testing.T) {
+	var listeners []*upgradeListener
+	for _, name := range []string{fileHTTP, fileAdmin} {
+		ln, err := upgradeListen(nil, name, "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners = append(listeners, ln)
+
+		server := http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}),
+		}
+		go ln.serve(&server)
+		defer server.Close()
+
+		// keep-alive connections of served requests, e.g. metrics scrapes, do not hold stop back
+		client := http.Client{Transport: &http.Transport{}}
+		defer client.CloseIdleConnections()
+
+		res, err := client.Get("http://" + ln.Addr().String())
+		if err != nil {
+			t.Fatalf("%s: got %v, want %v", name, err, nil)
+		}
+		res.Body.Close()
+	}
+
+	start := time.Now()
+	for i, ln := range listeners {
+		if err := ln.stop(acceptTimeout); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= acceptTimeout {
+		t.Errorf("got %v, want stop to return before %v", elapsed, acceptTimeout)
+	}
+}
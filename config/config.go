@@ -1,10 +1,17 @@
 package config
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/deividaspetraitis/fibonacci"
 	"github.com/deividaspetraitis/fibonacci/errors"
 	"github.com/deividaspetraitis/fibonacci/http"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/store"
+	"github.com/deividaspetraitis/fibonacci/tracing"
 
 	"github.com/spf13/viper"
 )
@@ -14,34 +21,106 @@ var ErrConfigNotFound = errors.New("config file were not found")
 
 // Config represents application configuration.
 type Config struct {
-	HTTP *http.Config `mapstructure:"http"` // HTTP server config.
+	HTTP      *http.Config          `mapstructure:"http"`      // HTTP server config.
+	Admin     *http.AdminConfig     `mapstructure:"admin"`     // Admin HTTP server config.
+	Tracing   *tracing.Config       `mapstructure:"tracing"`   // Tracing config.
+	Integrity *http.IntegrityConfig `mapstructure:"integrity"` // Integrity verification config.
+	Log       *log.Config           `mapstructure:"log"`       // Logging config.
+	Sequence  *fibonacci.Config     `mapstructure:"sequence"`  // Sequence counter config.
+	Store     *store.Config         `mapstructure:"store"`     // Counter state persistence config.
 }
 
-// New accepts constructs a new Config by reading env configuration file.
-func New(path string) (*Config, error) {
+// Default constructs a new Config holding built-in defaults.
+func Default() *Config {
+	return &Config{
+		HTTP: &http.Config{
+			Address:           ":8000",
+			ReadTimeout:       http.DefaultReadTimeout,
+			ReadHeaderTimeout: http.DefaultReadHeaderTimeout,
+			WriteTimeout:      http.DefaultWriteTimeout,
+			IdleTimeout:       http.DefaultIdleTimeout,
+			MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+			ShutdownTimeout:   http.DefaultShutdownTimeout,
+			WebSocket: http.WebSocketConfig{
+				RateLimit:    http.DefaultWebSocketRateLimit,
+				Burst:        http.DefaultWebSocketBurst,
+				PingInterval: http.DefaultWebSocketPingInterval,
+			},
+			AccessLog: http.AccessLogConfig{
+				SampleRate: 1,
+				Format:     log.FormatText,
+			},
+		},
+		Admin: &http.AdminConfig{},
+		Tracing: &tracing.Config{
+			Exporter: tracing.ExporterNone,
+		},
+		Integrity: &http.IntegrityConfig{
+			Interval: http.DefaultIntegrityInterval,
+		},
+		Log: &log.Config{
+			Level:  log.InfoLevel.String(),
+			Format: log.FormatText,
+			Output: log.OutputStderr,
+			File: log.FileConfig{
+				MaxSize: log.DefaultFileMaxSize,
+			},
+		},
+		Sequence: &fibonacci.Config{
+			Min:      0,
+			Max:      fibonacci.MaxThTerm,
+			Overflow: fibonacci.OverflowError,
+		},
+		Store: &store.Config{},
+	}
+}
+
+// New constructs a new Config layering, from the lowest precedence to the highest, built-in defaults,
+// optional configuration file at path, environment variables and flags set in fs.
+//
+// Configuration file type is detected by its extension: .yaml, .yml, .toml, .json, anything else is
+// considered an env file. Empty path means there is no configuration file, missing one is reported
+// as ErrConfigNotFound. Flags must be registered by RegisterFlags, fs might be nil.
+func New(path string, fs *flag.FlagSet) (*Config, error) {
 	parser := viper.NewWithOptions(
 		viper.KeyDelimiter("_"), // DATABASE_HOST instead of DATABASE.HOST in config file
 		viper.EnvKeyReplacer(strings.NewReplacer(".", "_")),
 	)
 
-	// Set config file
-	parser.SetConfigFile(path)
+	// Built-in defaults, they also make every setting known to be looked up in environment
+	for key, value := range flatten(Default()) {
+		parser.SetDefault(key, value.value)
+	}
 
-	// Set config type to env files
-	parser.SetConfigType("env")
+	// Read configuration file if any
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return nil, errors.Wrapf(ErrConfigNotFound, "%s", path)
+			}
+			return nil, errors.Wrapf(err, "failed reading config: %s", path)
+		}
 
-	// Check and load environment variables
-	parser.AutomaticEnv()
+		parser.SetConfigFile(path)
+		parser.SetConfigType(fileType(path))
 
-	// Read configuration values
-	if err := parser.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, errors.Wrapf(err, "unable to read config reading config: %s", path)
-		} else {
+		if err := parser.ReadInConfig(); err != nil {
 			return nil, errors.Wrapf(err, "failed reading config: %s", path)
 		}
 	}
 
+	// Check and load environment variables
+	parser.AutomaticEnv()
+
+	// Flags explicitly set on command line override everything else
+	if fs != nil {
+		fs.Visit(func(f *flag.Flag) {
+			if key, ok := flagKey(f.Name); ok {
+				parser.Set(key, f.Value.String())
+			}
+		})
+	}
+
 	// Populate configuration
 	var cfg Config
 	if err := parser.Unmarshal(&cfg); err != nil {
@@ -50,3 +129,32 @@ func New(path string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// Validate checks whether settings of c are sane and returns all problems found.
+func (c *Config) Validate() error {
+	errs := []error{
+		c.HTTP.Validate(),
+		c.Log.Validate(),
+		c.Tracing.Validate(),
+		c.Sequence.Validate(),
+	}
+
+	if c.Integrity.Interval <= 0 {
+		errs = append(errs, errors.Newf("integrity interval %s must be positive", c.Integrity.Interval))
+	}
+	if c.Admin.Address != "" && c.Admin.Address == c.HTTP.Address {
+		errs = append(errs, errors.Newf("admin address %s must differ from http address", c.Admin.Address))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fileType returns configuration type of file at path detected by its extension.
+func fileType(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".toml", ".json":
+		return strings.TrimPrefix(ext, ".")
+	default:
+		return "env"
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/http"
+)
+
+// writeFile writes data to file name in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	return path
+}
+
+func TestNewLayers(t *testing.T) {
+	path := writeFile(t, "serverd.env", "HTTP_ADDRESS=:8080\nLOG_LEVEL=warn\nLOG_FORMAT=json\nHTTP_RATELIMIT_ROUTES_NEXT_RATE=5\n")
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("HTTP_WRITETIMEOUT", "1m")
+
+	fs := flag.NewFlagSet("serverd", flag.ContinueOnError)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-log.level", "error", "-http.idletimeout", "5s"}); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	cfg, err := New(path, fs)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var testcases = []struct {
+		name string
+
+		got, want interface{}
+	}{
+		{name: "default", got: cfg.HTTP.ReadTimeout, want: 30 * time.Second},
+		{name: "file", got: cfg.HTTP.Address, want: ":8080"},
+		{name: "file", got: cfg.Log.Format, want: "json"},
+		{name: "file map", got: cfg.HTTP.RateLimit.Routes["next"].Rate, want: 5.0},
+		{name: "env", got: cfg.HTTP.WriteTimeout, want: time.Minute},
+		{name: "flag", got: cfg.HTTP.IdleTimeout, want: 5 * time.Second},
+		{name: "flag over env over file", got: cfg.Log.Level, want: "error"},
+	}
+
+	for i, tt := range testcases {
+		if tt.got != tt.want {
+			t.Errorf("#%d %s got %v, want %v", i, tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestNewFileTypes(t *testing.T) {
+	var testcases = []struct {
+		name string
+		data string
+	}{
+		{name: "serverd.env", data: "HTTP_ADDRESS=:9000\n"},
+		{name: "serverd.yaml", data: "http:\n  address: \":9000\"\n"},
+		{name: "serverd.toml", data: "[http]\naddress = \":9000\"\n"},
+		{name: "serverd.json", data: `{"http": {"address": ":9000"}}`},
+	}
+
+	for i, tt := range testcases {
+		cfg, err := New(writeFile(t, tt.name, tt.data), nil)
+		if err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+			continue
+		}
+		if cfg.HTTP.Address != ":9000" {
+			t.Errorf("#%d got %v, want %v", i, cfg.HTTP.Address, ":9000")
+		}
+	}
+}
+
+func TestNewWithoutFile(t *testing.T) {
+	cfg, err := New("", nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	if _, err := New(filepath.Join(t.TempDir(), "missing.env"), nil); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("got %v, want %v", err, ErrConfigNotFound)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.HTTP.ShutdownTimeout = 0
+	cfg.HTTP.DrainDelay = -time.Second
+	cfg.Log.Level = "loud"
+	cfg.Tracing.Exporter = "jaeger"
+	cfg.Integrity.Interval = 0
+	cfg.Admin.Address = cfg.HTTP.Address
+	cfg.Sequence.Overflow = "explode"
+
+	err := cfg.Validate()
+	for _, expected := range []string{"shutdown timeout", "drain delay", "log level", "tracing exporter", "integrity interval", "admin address", "overflow policy"} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("got %v, want %v", err, expected)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	settings := flatten(struct {
+		Address string        `mapstructure:"address"`
+		Token   string        `mapstructure:"token" secret:"true"`
+		Empty   string        `mapstructure:"empty" secret:"true"`
+		Timeout time.Duration `mapstructure:"timeout"`
+		Banner  string        `mapstructure:"banner"`
+	}{
+		Address: ":8000",
+		Token:   "s3cr3t",
+		Timeout: time.Second,
+		Banner:  "hello world",
+	})
+
+	var out bytes.Buffer
+	if err := printSettings(&out, settings); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	expected := "ADDRESS=:8000\nBANNER=\"hello world\"\nEMPTY=\nTIMEOUT=1s\nTOKEN=REDACTED\n"
+	if out.String() != expected {
+		t.Errorf("got %v, want %v", out.String(), expected)
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	cfg := Default()
+	cfg.HTTP.Address = ":9090"
+	cfg.HTTP.AccessLog.SampleRate = 0.25
+
+	var out bytes.Buffer
+	if err := Print(&out, cfg); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	parsed, err := New(writeFile(t, "printed.env", out.String()), nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if parsed.HTTP.Address != ":9090" || parsed.HTTP.AccessLog.SampleRate != 0.25 {
+		t.Errorf("got %v, want %v", parsed.HTTP, cfg.HTTP)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Default()
+
+	updated := Default()
+	updated.Log.Level = "debug"
+	updated.HTTP.RateLimit.Rate = 10
+	updated.HTTP.RateLimit.Routes = map[string]http.RateLimitBudget{"next": {Rate: 1}}
+
+	expected := []Change{
+		{Key: "HTTP_RATELIMIT_RATE", Old: "0", New: "10"},
+		{Key: "HTTP_RATELIMIT_ROUTES_NEXT_BURST", New: "0"},
+		{Key: "HTTP_RATELIMIT_ROUTES_NEXT_RATE", New: "1"},
+		{Key: "LOG_LEVEL", Old: "info", New: "debug"},
+	}
+
+	if got := Diff(old, updated); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+	if got := Diff(old, Default()); len(got) != 0 {
+		t.Errorf("got %v, want %v", got, nil)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change is a setting differing between two configurations.
+type Change struct {
+	Key string // setting key, for example HTTP_RATELIMIT_RATE
+	Old string // previous value formatted as in env file, empty when setting was not present
+	New string // current value formatted as in env file, empty when setting was removed
+}
+
+// Diff returns settings differing between old and new in lexical order of their keys.
+// Values of settings tagged secret are redacted, so changes are safe to log.
+func Diff(old, new *Config) []Change {
+	before, after := flatten(old), flatten(new)
+
+	keys := sortedKeys(before)
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		prev, ok := before[key]
+		next, found := after[key]
+		if ok && found && reflect.DeepEqual(prev.value, next.value) {
+			continue
+		}
+
+		change := Change{Key: strings.ToUpper(key)}
+		if ok {
+			change.Old = formatSetting(prev)
+		}
+		if found {
+			change.New = formatSetting(next)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// RegisterFlags registers a flag in fs for every setting having a built-in default, flags are named
+// after settings keys delimited by dots, for example -http.address overrides HTTP_ADDRESS.
+func RegisterFlags(fs *flag.FlagSet) {
+	settings := flatten(Default())
+	for _, key := range sortedKeys(settings) {
+		fs.String(strings.ReplaceAll(key, "_", "."), "", fmt.Sprintf("overrides %s", strings.ToUpper(key)))
+	}
+}
+
+// flagKey returns key of setting flag name overrides, it reports false for flags not registered by RegisterFlags.
+func flagKey(name string) (string, bool) {
+	key := strings.ReplaceAll(name, ".", "_")
+	_, ok := flatten(Default())[key]
+	return key, ok
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// setting is a single leaf setting of configuration.
+type setting struct {
+	value  interface{}
+	secret bool // value must not be revealed, fields tagged secret:"true"
+}
+
+// flatten returns leaf settings of v by key, keys are lower case mapstructure names of the path
+// to the setting joined by underscore, for example http_websocket_ratelimit.
+func flatten(v interface{}) map[string]setting {
+	settings := make(map[string]setting)
+	flattenValue(reflect.ValueOf(v), "", false, settings)
+	return settings
+}
+
+// flattenValue adds leaf settings of v found under prefix to settings.
+func flattenValue(v reflect.Value, prefix string, secret bool, settings map[string]setting) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		flattenValue(v.Elem(), prefix, secret, settings)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			fieldSecret := secret || field.Tag.Get("secret") == "true"
+
+			if opts == "squash" {
+				flattenValue(v.Field(i), prefix, fieldSecret, settings)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			flattenValue(v.Field(i), join(prefix, name), fieldSecret, settings)
+		}
+
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			flattenValue(v.MapIndex(key), join(prefix, key.String()), secret, settings)
+		}
+
+	default:
+		settings[prefix] = setting{value: v.Interface(), secret: secret}
+	}
+}
+
+// join joins prefix and name into a setting key.
+func join(prefix, name string) string {
+	name = strings.ToLower(name)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// sortedKeys returns keys of settings in lexical order.
+func sortedKeys(settings map[string]setting) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
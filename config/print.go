@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces values of secret settings when printed.
+const redacted = "REDACTED"
+
+// Print writes effective settings of cfg to w in env file format, so the output might be used as
+// configuration file itself. Values of settings tagged secret are redacted.
+func Print(w io.Writer, cfg *Config) error {
+	return printSettings(w, flatten(cfg))
+}
+
+// printSettings writes settings to w as KEY=value lines in lexical order.
+func printSettings(w io.Writer, settings map[string]setting) error {
+	for _, key := range sortedKeys(settings) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", strings.ToUpper(key), formatSetting(settings[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSetting formats value of s as it would be written in env file.
+func formatSetting(s setting) string {
+	var value string
+	switch v := s.value.(type) {
+	case time.Duration:
+		value = v.String()
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	if s.secret && value != "" {
+		return redacted
+	}
+	if strings.ContainsAny(value, " \t#\"'\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -3,59 +3,316 @@ package fibonacci
 import (
 	"context"
 	"math/big"
+	"math/bits"
 	"sync"
 
 	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// MaxThTerm defines a maximum th term computed for Fibonacci sequence.
+// MaxThTerm defines a maximum th term computed for Fibonacci sequence, the highest one fitting into int64.
 const MaxThTerm = 92
 
+// tracer records spans of counter operations.
+var tracer = otel.Tracer("github.com/deividaspetraitis/fibonacci")
+
 // Fibonacci Errors
 var (
 	ErrCounterOverflow  = errors.New("fibonacci: next term overflows highest allowed term in the sequence")
 	ErrCounterUnderflow = errors.New("fibonacci: next term underflows lowest allowed term in the sequence")
+	ErrCounterCorrupted = errors.New("fibonacci: counter invariant does not hold")
 )
 
 // Fibonacci implements walking through the sequence.
+// Zero value walks the sequence as configured by DefaultConfig, SetConfig changes bounds and overflow policy.
 // It is safe to use Fibonacci concurrently.
 type Fibonacci struct {
-	// counter is safe to use concurrently.
+	// counter, term and cfg are safe to use concurrently.
 	mu      sync.Mutex
 	counter int
+	term    cachedTerm // number counter points to, so reading it does not recalculate it
+	cfg     *Config    // nil means DefaultConfig
 }
 
-// CurrentFibonacciNumber returns the current number in the Fibonacci sequence.
-func (f *Fibonacci) CurrentFibonacciNumber(ctx context.Context) (int64, error) {
+// cachedTerm is a number of the sequence cached along with its position, zero value caches nothing.
+type cachedTerm struct {
+	n     int
+	value int64
+	ok    bool
+}
+
+// SetConfig replaces bounds and overflow policy of f with ones configured by cfg.
+// Counter pointing outside of the new bounds is moved to the closest one.
+func (f *Fibonacci) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Overflow == "" {
+		cfg.Overflow = OverflowError
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	return calcFiboncciTerm(f.counter), nil
+
+	f.cfg = &cfg
+	if f.counter < cfg.Min {
+		f.counter, f.term = cfg.Min, cachedTerm{}
+	}
+	if f.counter > cfg.Max {
+		f.counter, f.term = cfg.Max, cachedTerm{}
+	}
+	return nil
 }
 
-// GetNextFibonacciNumberFunc responds with the next number in the Fibonacci sequence.
-func (f *Fibonacci) NextFibonacciNumber(ctx context.Context) (int64, error) {
+// Config returns bounds and overflow policy of f.
+func (f *Fibonacci) Config() Config {
 	f.mu.Lock()
-	// MaxThTerm term in the sequence is the largest to fix into uint.
-	if (f.counter + 1) > MaxThTerm {
-		defer f.mu.Unlock()
-		return 0, ErrCounterOverflow
-	} else {
-		f.counter++
+	defer f.mu.Unlock()
+	return f.config()
+}
+
+// config returns bounds and overflow policy of f, f.mu must be held.
+func (f *Fibonacci) config() Config {
+	if f.cfg == nil {
+		return DefaultConfig()
 	}
+	return *f.cfg
+}
+
+// Position returns the current counter position, that is n th term of the sequence counter points to.
+func (f *Fibonacci) Position() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counter
+}
+
+// Verify checks whether counter invariants hold and returns ErrCounterCorrupted describing the first one that does not.
+func (f *Fibonacci) Verify(ctx context.Context) error {
+	f.mu.Lock()
+	n, term, cfg := f.counter, f.term, f.config()
 	f.mu.Unlock()
-	return calcFiboncciTerm(f.counter), nil
+
+	if n < cfg.Min || n > cfg.Max {
+		return errors.Wrapf(ErrCounterCorrupted, "counter %d is out of range [%d, %d]", n, cfg.Min, cfg.Max)
+	}
+	if !term.ok {
+		return nil
+	}
+
+	// Cached number is served instead of calculating it, so it must be the one counter points to.
+	if term.n != n {
+		return errors.Wrapf(ErrCounterCorrupted, "cached %dth term does not match counter %d", term.n, n)
+	}
+	if want := binetTerm(n); term.value != want {
+		return errors.Wrapf(ErrCounterCorrupted, "cached %dth term %d does not match closed form %d", n, term.value, want)
+	}
+
+	return nil
+}
+
+// Number is a number of the Fibonacci sequence along with its position in the sequence.
+type Number struct {
+	Index int   // n th term of the sequence
+	Value int64 // the number
+}
+
+// CurrentFibonacciNumber returns the current number in the Fibonacci sequence.
+func (f *Fibonacci) CurrentFibonacciNumber(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.CurrentFibonacciNumber")
+	defer span.End()
+
+	return f.current(ctx).Value, nil
+}
+
+// CurrentNumber returns the current number in the Fibonacci sequence along with its position.
+func (f *Fibonacci) CurrentNumber(ctx context.Context) (Number, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.CurrentNumber")
+	defer span.End()
+
+	return f.current(ctx), nil
+}
+
+// current returns the number counter points to.
+func (f *Fibonacci) current(ctx context.Context) Number {
+	f.lock(ctx)
+	defer f.mu.Unlock()
+
+	if f.term.ok && f.term.n == f.counter {
+		return Number{Index: f.term.n, Value: f.term.value}
+	}
+	f.move(ctx, f.counter)
+	return Number{Index: f.term.n, Value: f.term.value}
+}
+
+// move points the counter to n th term caching its number, f.mu must be held.
+func (f *Fibonacci) move(ctx context.Context, n int) {
+	f.counter = n
+	f.term = cachedTerm{n: n, value: calcTerm(ctx, n), ok: true}
+}
+
+// GetNextFibonacciNumberFunc responds with the next number in the Fibonacci sequence.
+// Stepping past the highest allowed term is handled by the configured overflow policy.
+func (f *Fibonacci) NextFibonacciNumber(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.NextFibonacciNumber")
+	defer span.End()
+
+	number, err := f.step(ctx, span, 1)
+	return number.Value, err
+}
+
+// NextNumber responds with the next number in the Fibonacci sequence along with its position.
+// Stepping past the highest allowed term is handled by the configured overflow policy.
+func (f *Fibonacci) NextNumber(ctx context.Context) (Number, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.NextNumber")
+	defer span.End()
+
+	return f.step(ctx, span, 1)
 }
 
 // GetPreviousFibonacciNumberFunc responds with the next number in the Fibonacci sequence.
+// Stepping past the lowest allowed term is handled by the configured overflow policy.
 func (f *Fibonacci) PreviousFibonacciNumber(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.PreviousFibonacciNumber")
+	defer span.End()
+
+	number, err := f.step(ctx, span, -1)
+	return number.Value, err
+}
+
+// PreviousNumber responds with the previous number in the Fibonacci sequence along with its position.
+// Stepping past the lowest allowed term is handled by the configured overflow policy.
+func (f *Fibonacci) PreviousNumber(ctx context.Context) (Number, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.PreviousNumber")
+	defer span.End()
+
+	return f.step(ctx, span, -1)
+}
+
+// step moves the counter by delta, which is either 1 or -1, and responds with the number it points to.
+func (f *Fibonacci) step(ctx context.Context, span trace.Span, delta int) (Number, error) {
+	f.lock(ctx)
+	cfg := f.config()
+	n, err := cfg.step(f.counter, delta)
+	if n != f.counter+delta {
+		log.WithContext(ctx).WithFields(log.Fields{"policy": cfg.Overflow}).Debug("counter reached the bound of allowed terms")
+	}
+	if err != nil {
+		f.mu.Unlock()
+		return Number{}, spanError(span, err)
+	}
+	f.move(ctx, n)
+	term := f.term
+	f.mu.Unlock()
+	return Number{Index: term.n, Value: term.value}, nil
+}
+
+// SeekFibonacciNumber moves the counter to n th term and responds with its number in the Fibonacci sequence.
+// Terms out of the configured bounds are rejected regardless of overflow policy.
+func (f *Fibonacci) SeekFibonacciNumber(ctx context.Context, n int) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Fibonacci.SeekFibonacciNumber", trace.WithAttributes(attribute.Int("fibonacci.term", n)))
+	defer span.End()
+
+	f.lock(ctx)
+	cfg := f.config()
+	if n > cfg.Max {
+		f.mu.Unlock()
+		return 0, spanError(span, ErrCounterOverflow)
+	}
+	if n < cfg.Min {
+		f.mu.Unlock()
+		return 0, spanError(span, ErrCounterUnderflow)
+	}
+	f.move(ctx, n)
+	value := f.term.value
+	f.mu.Unlock()
+	return value, nil
+}
+
+// lock acquires f.mu recording time spent waiting for it as a span.
+func (f *Fibonacci) lock(ctx context.Context) {
+	_, span := tracer.Start(ctx, "Fibonacci.lock")
 	f.mu.Lock()
-	if (f.counter - 1) < 0 {
-		defer f.mu.Unlock()
+	span.End()
+}
+
+// calcTerm calculates n th term of the sequence recording calculation time as a span.
+func calcTerm(ctx context.Context, n int) int64 {
+	_, span := tracer.Start(ctx, "calcFiboncciTerm", trace.WithAttributes(attribute.Int("fibonacci.term", n)))
+	defer span.End()
+	return calcFiboncciTerm(n)
+}
+
+// spanError records err in span and returns it.
+func spanError(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}
+
+// Term returns n th term of the Fibonacci sequence without moving any counter.
+func Term(n int) (int64, error) {
+	if n > MaxThTerm {
+		return 0, ErrCounterOverflow
+	}
+	if n < 0 {
 		return 0, ErrCounterUnderflow
 	}
-	f.counter--
-	f.mu.Unlock()
-	return calcFiboncciTerm(f.counter), nil
+	return calcFiboncciTerm(n), nil
+}
+
+// BigTermPair returns n th and n+1 th terms of the Fibonacci sequence.
+// Unlike Term it is not bounded by MaxThTerm, terms are calculated using fast doubling in O(log n) steps:
+//
+//	F(2k)   = F(k) * (2*F(k+1) - F(k))
+//	F(2k+1) = F(k+1)^2 + F(k)^2
+func BigTermPair(n uint64) (*big.Int, *big.Int) {
+	a, b := big.NewInt(0), big.NewInt(1) // F(k), F(k+1) where k = 0
+	c, d := new(big.Int), new(big.Int)
+	for i := bits.Len64(n) - 1; i >= 0; i-- {
+		c.Lsh(b, 1)
+		c.Sub(c, a)
+		c.Mul(c, a) // F(2k)
+
+		d.Mul(b, b)
+		a.Mul(a, a)
+		d.Add(d, a) // F(2k+1)
+
+		a.Set(c)
+		b.Set(d)
+		if (n>>uint(i))&1 == 1 {
+			a.Add(a, b)
+			a, b = b, a // F(2k+1), F(2k+2)
+		}
+	}
+	return a, b
+}
+
+// binetTerm calculates n th term of the Fibonacci sequence using Binet's formula round(phi^n / sqrt(5)).
+// Precision of float64 is not enough for higher terms, thus big.Float is used instead.
+func binetTerm(n int) int64 {
+	const prec = 256
+
+	sqrt5 := new(big.Float).SetPrec(prec).SetInt64(5)
+	sqrt5.Sqrt(sqrt5)
+
+	phi := new(big.Float).SetPrec(prec).SetInt64(1)
+	phi.Add(phi, sqrt5)
+	phi.Quo(phi, big.NewFloat(2))
+
+	f := new(big.Float).SetPrec(prec).SetInt64(1)
+	for i := 0; i < n; i++ {
+		f.Mul(f, phi)
+	}
+	f.Quo(f, sqrt5)
+	f.Add(f, big.NewFloat(0.5))
+
+	term, _ := f.Int(nil)
+	return term.Int64()
 }
 
 // calcFiboncciTerm calculates and returns n th term of the Fibonacci sequence.
@@ -141,3 +141,202 @@ func TestCalcFiboncciTerm(t *testing.T) {
 		}
 	}
 }
+
+func TestSeekFibonacciNumber(t *testing.T) {
+	var testcases = []struct {
+		n int
+
+		counter  int
+		expected int64
+		err      error
+	}{
+		{
+			n:        44,
+			counter:  44,
+			expected: 701408733,
+		},
+		{
+			n:        MaxThTerm,
+			counter:  MaxThTerm,
+			expected: 7540113804746346429,
+		},
+		{
+			n:       MaxThTerm + 1,
+			counter: 10,
+			err:     ErrCounterOverflow,
+		},
+		{
+			n:       -1,
+			counter: 10,
+			err:     ErrCounterUnderflow,
+		},
+	}
+
+	for _, tt := range testcases {
+		sequence := Fibonacci{counter: 10}
+
+		got, err := sequence.SeekFibonacciNumber(context.TODO(), tt.n)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%dth got %v, want %v", tt.n, err, tt.err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("#%dth got %v, want %v", tt.n, got, tt.expected)
+		}
+
+		if sequence.counter != tt.counter {
+			t.Errorf("#%dth counter got %v, want %v", tt.n, sequence.counter, tt.counter)
+		}
+	}
+}
+
+func TestTerm(t *testing.T) {
+	var testcases = []struct {
+		n int
+
+		expected int64
+		err      error
+	}{
+		{
+			n:        0,
+			expected: 0,
+		},
+		{
+			n:        10,
+			expected: 55,
+		},
+		{
+			n:        MaxThTerm,
+			expected: 7540113804746346429,
+		},
+		{
+			n:   MaxThTerm + 1,
+			err: ErrCounterOverflow,
+		},
+		{
+			n:   -1,
+			err: ErrCounterUnderflow,
+		},
+	}
+
+	for _, tt := range testcases {
+		got, err := Term(tt.n)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%dth got %v, want %v", tt.n, err, tt.err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("#%dth got %v, want %v", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestBigTermPair(t *testing.T) {
+	// cross check against iterative calculation within int64 bounds
+	for n := 0; n < MaxThTerm; n++ {
+		fn, fn1 := BigTermPair(uint64(n))
+		if got, want := fn.Int64(), calcFiboncciTerm(n); got != want {
+			t.Errorf("#%dth got %v, want %v", n, got, want)
+		}
+		if got, want := fn1.Int64(), calcFiboncciTerm(n+1); got != want {
+			t.Errorf("#%dth got %v, want %v", n+1, got, want)
+		}
+	}
+
+	var testcases = []struct {
+		n uint64
+
+		expected string
+	}{
+		{
+			n:        100,
+			expected: "354224848179261915075",
+		},
+		{
+			n:        300,
+			expected: "222232244629420445529739893461909967206666939096499764990979600",
+		},
+	}
+
+	for _, tt := range testcases {
+		got, _ := BigTermPair(tt.n)
+		if got.String() != tt.expected {
+			t.Errorf("#%dth got %v, want %v", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var testcases = []struct {
+		sequence *Fibonacci
+
+		err error
+	}{
+		{
+			sequence: &Fibonacci{counter: 0},
+		},
+		{
+			sequence: &Fibonacci{counter: MaxThTerm},
+		},
+		{
+			sequence: &Fibonacci{counter: MaxThTerm + 1},
+			err:      ErrCounterCorrupted,
+		},
+		{
+			sequence: &Fibonacci{counter: -1},
+			err:      ErrCounterCorrupted,
+		},
+		{
+			sequence: &Fibonacci{counter: 10, term: cachedTerm{n: 10, value: 55, ok: true}},
+		},
+		{
+			sequence: &Fibonacci{counter: 10, term: cachedTerm{n: 10, value: 56, ok: true}},
+			err:      ErrCounterCorrupted,
+		},
+		{
+			sequence: &Fibonacci{counter: 11, term: cachedTerm{n: 10, value: 55, ok: true}},
+			err:      ErrCounterCorrupted,
+		},
+	}
+
+	for _, tt := range testcases {
+		if err := tt.sequence.Verify(context.TODO()); !errors.Is(err, tt.err) {
+			t.Errorf("#%dth got %v, want %v", tt.sequence.counter, err, tt.err)
+		}
+	}
+}
+
+func TestBinetTerm(t *testing.T) {
+	for n := 0; n <= MaxThTerm; n++ {
+		if got, want := binetTerm(n), calcFiboncciTerm(n); got != want {
+			t.Errorf("#%dth got %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	var testcases = []struct {
+		counter int
+		walk    func(f *Fibonacci) (Number, error)
+
+		expected Number
+		err      error
+	}{
+		{counter: 10, walk: func(f *Fibonacci) (Number, error) { return f.CurrentNumber(context.TODO()) }, expected: Number{Index: 10, Value: 55}},
+		{counter: 10, walk: func(f *Fibonacci) (Number, error) { return f.NextNumber(context.TODO()) }, expected: Number{Index: 11, Value: 89}},
+		{counter: 10, walk: func(f *Fibonacci) (Number, error) { return f.PreviousNumber(context.TODO()) }, expected: Number{Index: 9, Value: 34}},
+		{counter: 1, walk: func(f *Fibonacci) (Number, error) { return f.NextNumber(context.TODO()) }, expected: Number{Index: 2, Value: 1}},
+		{counter: MaxThTerm, walk: func(f *Fibonacci) (Number, error) { return f.NextNumber(context.TODO()) }, err: ErrCounterOverflow},
+		{counter: 0, walk: func(f *Fibonacci) (Number, error) { return f.PreviousNumber(context.TODO()) }, err: ErrCounterUnderflow},
+	}
+
+	for i, tt := range testcases {
+		number, err := tt.walk(&Fibonacci{counter: tt.counter})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if number != tt.expected {
+			t.Errorf("#%d got %v, want %v", i, number, tt.expected)
+		}
+	}
+}
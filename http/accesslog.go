@@ -0,0 +1,55 @@
+package http
+
+import (
+	"math/rand"
+	"net/http"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+)
+
+// AccessLog logs method, route template, status, bytes written, latency, remote address, request ID and
+// trace ID of served requests using logger.
+//
+// Only cfg.SampleRate fraction of successful requests is logged, none when it is zero, failed ones, including
+// rejected by authentication or rate limiting, are always logged.
+func AccessLog(logger log.Logger, cfg AccessLogConfig) mux.MiddlewareFunc {
+	switch cfg.Format {
+	case "", log.FormatText:
+	case log.FormatJSON, log.FormatLogfmt:
+		logger = log.WithFormat(logger, cfg.Format)
+	default:
+		logger.Warnf("unknown access log format %q, falling back to %s", cfg.Format, log.FormatText)
+	}
+
+	sampled := func() bool {
+		return cfg.SampleRate >= 1 || rand.Float64() < cfg.SampleRate
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			snoop := httpsnoop.CaptureMetrics(next, w, r)
+
+			if snoop.Code < http.StatusBadRequest && !sampled() {
+				return
+			}
+
+			route := ""
+			if current := mux.CurrentRoute(r); current != nil {
+				route, _ = current.GetPathTemplate()
+			}
+
+			// request and trace IDs are added by context
+			logger.WithContext(r.Context()).WithFields(log.Fields{
+				"method":      r.Method,
+				"route":       route,
+				"status":      snoop.Code,
+				"bytes":       snoop.Written,
+				"latency":     snoop.Duration.String(),
+				"remote_addr": r.RemoteAddr,
+			}).Info("request served")
+		})
+	}
+}
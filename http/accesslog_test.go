@@ -0,0 +1,126 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAccessLog(t *testing.T) {
+	var testcases = []struct {
+		cfg        AccessLogConfig
+		statusCode int
+
+		entries int
+	}{
+		// everything is logged
+		{
+			cfg:        AccessLogConfig{SampleRate: 1},
+			statusCode: http.StatusOK,
+			entries:    10,
+		},
+		// successful requests are not logged at all
+		{
+			cfg:        AccessLogConfig{},
+			statusCode: http.StatusOK,
+			entries:    0,
+		},
+		// successful requests are sampled
+		{
+			cfg:        AccessLogConfig{SampleRate: 0.000001},
+			statusCode: http.StatusOK,
+			entries:    0,
+		},
+		// failed requests are always logged
+		{
+			cfg:        AccessLogConfig{SampleRate: 0.000001},
+			statusCode: http.StatusInternalServerError,
+			entries:    10,
+		},
+		{
+			cfg:        AccessLogConfig{},
+			statusCode: http.StatusTooManyRequests,
+			entries:    10,
+		},
+		{
+			cfg:        AccessLogConfig{},
+			statusCode: http.StatusNotFound,
+			entries:    10,
+		},
+	}
+
+	for i, tt := range testcases {
+		var out bytes.Buffer
+		logger := log.New(&out)
+
+		router := mux.NewRouter()
+		router.Use(AccessLog(logger, tt.cfg))
+		router.HandleFunc("/current", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.statusCode)
+		})
+
+		for j := 0; j < 10; j++ {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/current", nil))
+		}
+
+		if entries := strings.Count(out.String(), "request served"); entries != tt.entries {
+			t.Errorf("#%d entries got %v, want %v", i, entries, tt.entries)
+		}
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.New(&out)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+			next.ServeHTTP(w, r.WithContext(trace.ContextWithSpanContext(r.Context(), sc)))
+		})
+	}
+
+	router := mux.NewRouter()
+	router.Use(RequestID, traced, AccessLog(logger, AccessLogConfig{Format: "json", SampleRate: 1}))
+	router.HandleFunc("/sequence/{n}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("tea"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/sequence/10", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	for field, expected := range map[string]interface{}{
+		"method":      http.MethodGet,
+		"route":       "/sequence/{n}",
+		"status":      float64(http.StatusTeapot),
+		"bytes":       float64(3),
+		"remote_addr": req.RemoteAddr,
+		"request_id":  "abc",
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+	} {
+		if entry[field] != expected {
+			t.Errorf("%s got %v, want %v", field, entry[field], expected)
+		}
+	}
+
+	if _, ok := entry["latency"]; !ok {
+		t.Errorf("latency got none, want some")
+	}
+}
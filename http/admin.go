@@ -0,0 +1,166 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+
+	"github.com/gorilla/mux"
+)
+
+// ConfigPrinter writes effective configuration to w with secrets redacted.
+type ConfigPrinter func(w io.Writer) error
+
+// Admin constructs an http.Handler with administrative routes defined: metrics, health probes, pprof
+// profiles, profiles captured to files by profiles, configuration printed by printConfig and counter administration.
+// It is meant to be served on a separate listener, not exposed publicly.
+//
+// Routes other than metrics and health probes require ScopeAdmin to be granted by auth, authentication
+// is disabled when auth is nil. Profile capture routes are not served when profiles is nil.
+func Admin(app *fibonacci.Fibonacci, metrics *Metrics, health *Health, printConfig ConfigPrinter, profiles *Profiles, auth Authenticator, logger log.Logger) http.Handler {
+	router := mux.NewRouter()
+	router.Use(injectLogger(logger), RequestID)
+
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	// Responses of pkg/api/v1 types are encoded in media type negotiated by Accept header.
+	negotiate := Negotiate(DefaultCodecs)
+
+	router.Handle("/healthz", negotiate(GetHealthz(health))).Methods(http.MethodGet)
+	router.Handle("/readyz", negotiate(GetReadyz(health))).Methods(http.MethodGet)
+
+	admin := func(h http.HandlerFunc) http.Handler {
+		return Authorize(auth, ScopeAdmin, h)
+	}
+
+	router.Handle("/config", admin(GetConfig(printConfig))).Methods(http.MethodGet)
+
+	// Record counter errors no matter which interface moved the counter.
+	seq := &instrumentedStepper{stepper: app, metrics: metrics}
+
+	router.Handle("/counter", negotiate(admin(GetCounter(app)))).Methods(http.MethodGet)
+	router.Handle("/counter/reset", negotiate(admin(ResetCounter(app, seq.SeekFibonacciNumber)))).Methods(http.MethodPost)
+	router.Handle("/counter/seek", negotiate(admin(SeekCounter(app, seq.SeekFibonacciNumber)))).Methods(http.MethodPost)
+
+	if profiles != nil {
+		for _, kind := range []string{ProfileCPU, ProfileHeap, ProfileTrace} {
+			router.Handle("/debug/capture/"+kind, negotiate(admin(CaptureProfile(profiles, kind)))).Methods(http.MethodPost)
+		}
+	}
+
+	router.Handle("/debug/pprof/cmdline", admin(pprof.Cmdline))
+	router.Handle("/debug/pprof/profile", admin(pprof.Profile))
+	router.Handle("/debug/pprof/symbol", admin(pprof.Symbol))
+	router.Handle("/debug/pprof/trace", admin(pprof.Trace))
+	router.PathPrefix("/debug/pprof/").Handler(admin(pprof.Index))
+
+	return router
+}
+
+// GetConfig responds with effective configuration printed by printConfig in env file format.
+func GetConfig(printConfig ConfigPrinter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if err := printConfig(w); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "admin",
+				"method":  "GetConfig",
+			}).Println("unable to print configuration")
+		}
+	}
+}
+
+// GetCounter responds with a snapshot of the counter.
+func GetCounter(app *fibonacci.Fibonacci) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondCounter(w, r, "GetCounter", app)
+	}
+}
+
+// seekFunc moves the counter to n th term, it decouples moving the counter from reading its snapshot,
+// so moves can be instrumented.
+type seekFunc func(ctx context.Context, n int) (int64, error)
+
+// ResetCounter moves the counter of app to the lowest allowed term using seek and responds with its snapshot.
+func ResetCounter(app *fibonacci.Fibonacci, seek seekFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := seek(r.Context(), app.Config().Min); err != nil {
+			respondSeekError(w, r, "ResetCounter", err)
+			return
+		}
+
+		log.WithContext(r.Context()).Info("counter reset")
+		respondCounter(w, r, "ResetCounter", app)
+	}
+}
+
+// SeekCounter moves the counter of app to the term requested by api.SeekRequest using seek and responds with its snapshot.
+func SeekCounter(app *fibonacci.Fibonacci, seek seekFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req api.SeekRequest
+		if err := UnmarshalRequest(r, &req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		if _, err := seek(r.Context(), req.Term); err != nil {
+			respondSeekError(w, r, "SeekCounter", err)
+			return
+		}
+
+		log.WithContext(r.Context()).WithFields(log.Fields{"term": req.Term}).Info("counter moved")
+		respondCounter(w, r, "SeekCounter", app)
+	}
+}
+
+// respondCounter responds with a snapshot of app counter.
+func respondCounter(w http.ResponseWriter, r *http.Request, method string, app *fibonacci.Fibonacci) {
+	cfg, position := app.Config(), app.Position()
+
+	current, err := fibonacci.Term(position)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "admin",
+			"method":  method,
+		}).Println("encountered an error retrieving Fibonacci number")
+
+		respondError(w, r, http.StatusInternalServerError, "counter corrupted")
+		return
+	}
+
+	if err := Respond(w, r, http.StatusOK, &api.CounterSnapshot{
+		Position: position,
+		Current:  current,
+		Min:      cfg.Min,
+		Max:      cfg.Max,
+		Overflow: string(cfg.Overflow),
+	}); err != nil {
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "admin",
+			"method":  method,
+		}).Println("unable to marshal response data")
+	}
+}
+
+// respondSeekError responds with err moving the counter failed with.
+func respondSeekError(w http.ResponseWriter, r *http.Request, method string, err error) {
+	switch {
+	case errors.Is(err, fibonacci.ErrCounterOverflow):
+		respondError(w, r, http.StatusUnprocessableEntity, "counter overflow")
+	case errors.Is(err, fibonacci.ErrCounterUnderflow):
+		respondError(w, r, http.StatusUnprocessableEntity, "counter underflow")
+	default:
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "admin",
+			"method":  method,
+		}).Println("encountered an error moving the counter")
+
+		respondError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
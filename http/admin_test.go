@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+func TestAdminCounter(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	if err := sequence.SetConfig(fibonacci.Config{Min: 2, Max: 20}); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	handler := Admin(&sequence, NewMetrics(&sequence), NewHealth(), nil, nil, nil, log.Discard())
+
+	var testcases = []struct {
+		method string
+		path   string
+		body   string
+
+		code     int
+		position int
+		message  string
+	}{
+		{method: http.MethodGet, path: "/counter", code: http.StatusOK, position: 2},
+		{method: http.MethodPost, path: "/counter/seek", body: `{"term": 10}`, code: http.StatusOK, position: 10},
+		{method: http.MethodPost, path: "/counter/seek", body: `{"term": 21}`, code: http.StatusUnprocessableEntity, message: "counter overflow"},
+		{method: http.MethodPost, path: "/counter/seek", body: `{"term": 1}`, code: http.StatusUnprocessableEntity, message: "counter underflow"},
+		{method: http.MethodPost, path: "/counter/seek", body: `{"term":`, code: http.StatusBadRequest, message: "invalid request"},
+		{method: http.MethodGet, path: "/counter", code: http.StatusOK, position: 10},
+		{method: http.MethodPost, path: "/counter/reset", code: http.StatusOK, position: 2},
+		{method: http.MethodGet, path: "/counter/reset", code: http.StatusMethodNotAllowed},
+	}
+
+	for i, tt := range testcases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+		if w.Code != tt.code {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, tt.code)
+		}
+
+		switch {
+		case tt.message != "":
+			var body api.Error
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("#%d got %v, want %v", i, err, nil)
+			}
+			if body.Message != tt.message {
+				t.Errorf("#%d got %v, want %v", i, body.Message, tt.message)
+			}
+
+		case tt.code == http.StatusOK:
+			var snapshot api.CounterSnapshot
+			if err := json.NewDecoder(w.Body).Decode(&snapshot); err != nil {
+				t.Fatalf("#%d got %v, want %v", i, err, nil)
+			}
+
+			current, _ := fibonacci.Term(tt.position)
+			expected := api.CounterSnapshot{Position: tt.position, Current: current, Min: 2, Max: 20, Overflow: "error"}
+			if snapshot != expected {
+				t.Errorf("#%d got %v, want %v", i, snapshot, expected)
+			}
+		}
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	printConfig := func(w io.Writer) error {
+		_, err := io.WriteString(w, "HTTP_ADDRESS=:8000\n")
+		return err
+	}
+
+	handler := Admin(&sequence, NewMetrics(&sequence), NewHealth(), printConfig, nil, nil, log.Discard())
+
+	var testcases = []struct {
+		path string
+
+		contains string
+	}{
+		{path: "/config", contains: "HTTP_ADDRESS=:8000"},
+		{path: "/debug/pprof/", contains: "goroutine"},
+		{path: "/debug/pprof/cmdline", contains: ""},
+	}
+
+	for i, tt := range testcases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, http.StatusOK)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("#%d got %v, want %v", i, w.Body.String(), tt.contains)
+		}
+	}
+}
@@ -6,8 +6,10 @@ import (
 	stdhttp "net/http"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
 	"github.com/deividaspetraitis/fibonacci/log"
 
 	"github.com/gorilla/handlers"
@@ -20,54 +22,206 @@ import (
 type App struct {
 	API      *mux.Router
 	shutdown chan os.Signal
+	logger   log.Logger
+
+	// settings changing at runtime, attached by API
+	limiter *RateLimiter
+	auth    Authenticator
+
+	hub *Hub // WebSocket clients
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
-func NewApp(shutdown chan os.Signal) *App {
+// The logger is made available to handlers through request context.
+func NewApp(shutdown chan os.Signal, logger log.Logger) *App {
 	api := App{
 		API:      mux.NewRouter(),
 		shutdown: shutdown,
+		logger:   logger,
+		hub:      NewHub(),
 	}
+	api.API.Use(injectLogger(logger))
 	return &api
 }
 
+// Logger returns logger of the app.
+func (a *App) Logger() log.Logger {
+	return a.logger
+}
+
 // ServeHTTP API
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.API.ServeHTTP(w, r)
 }
 
+// Reload applies settings of cfg safe to change at runtime to the running app: rate limits are
+// replaced and authentication credentials reloaded from their files. Settings requiring a restart
+// are ignored. When any credentials fail to reload nothing is changed and the error is returned.
+func (a *App) Reload(cfg *Config) error {
+	apply, err := a.StageReload(cfg)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// StageReload loads settings of cfg like Reload does, returning function applying them to the running app
+// which never fails. Nothing is changed until it is called, so reload might be aborted once other
+// components fail to load their settings.
+func (a *App) StageReload(cfg *Config) (apply func(), err error) {
+	applyAuth := func() {}
+	if s, ok := a.auth.(stager); ok {
+		if applyAuth, err = s.stage(); err != nil {
+			return nil, errors.Wrap(err, "reloading credentials")
+		}
+	}
+
+	return func() {
+		applyAuth()
+		if a.limiter != nil {
+			a.limiter.SetConfig(cfg.RateLimit)
+		}
+	}, nil
+}
+
+// Shutdown disconnects WebSocket clients and waits for commands being executed to complete until ctx is done.
+// WebSocket connections are hijacked, thus http.Server.Shutdown neither closes nor waits for them.
+func (a *App) Shutdown(ctx context.Context) error {
+	return a.hub.Close(ctx)
+}
+
 // SignalShutdown is used to gracefully shutdown the app when an integrity
 // issue is identified.
 func (a *App) SignalShutdown() {
-	a.shutdown <- syscall.SIGTERM
+	// SIGSTOP can not be caught from the OS, so it unambiguously identifies shutdown caused by integrity issue.
+	select {
+	case a.shutdown <- syscall.SIGSTOP:
+	default:
+		// Shutdown is already in progress.
+	}
 }
 
-// API constructs an http.Handler with all application routes defined.
-func API(shutdown chan os.Signal, cfg *Config, app *fibonacci.Fibonacci, logger log.Logger) stdhttp.Handler {
+// VerifyIntegrity runs check every interval until ctx is done.
+// Once the check fails the failure is logged and the app is shut down.
+func (a *App) VerifyIntegrity(ctx context.Context, interval time.Duration, check Checker) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := check.Check(ctx); err != nil {
+				a.logger.WithContext(ctx).WithError(err).WithFields(log.Fields{
+					"handler": "app",
+					"method":  "VerifyIntegrity",
+				}).Error("integrity check failed, shutting down")
+
+				a.SignalShutdown()
+				return
+			}
+		}
+	}
+}
+
+// API constructs an http.Handler with all application routes attached to api.
+// Routes reading the counter require ScopeRead and routes moving it ScopeWrite to be granted by auth,
+// authentication is disabled when auth is nil.
+func API(api *App, cfg *Config, app *fibonacci.Fibonacci, metrics *Metrics, health *Health, auth Authenticator) stdhttp.Handler {
 	// =========================================================================
 	// Construct the web app api which holds all routes as well as common Middleware.
 
-	api := NewApp(shutdown)
+	middleware := []mux.MiddlewareFunc{RequestID, Tracing, metrics.Middleware, AccessLog(api.logger, cfg.AccessLog)}
+	api.API.Use(middleware...)
+
+	// Router applies middleware to matched routes only, so requests matching none are observed the same way here.
+	unmatched := append([]mux.MiddlewareFunc{injectLogger(api.logger)}, middleware...)
+	api.API.NotFoundHandler = chain(http.NotFoundHandler(), unmatched...)
+	api.API.MethodNotAllowedHandler = chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}), unmatched...)
+
+	// Record counter errors no matter which interface moved the counter.
+	seq := &instrumentedStepper{stepper: app, metrics: metrics}
+	numbers := &instrumentedNumbers{numberStepper: app, metrics: metrics}
+
+	// Single client must not be able to exhaust the shared counter for everyone, probes are never limited.
+	// Budgets are shared by all versions of a route, they move the same counter.
+	limiter := NewRateLimiter(cfg.RateLimit)
+
+	api.limiter, api.auth = limiter, auth
+
+	// Responses are encoded in media type negotiated by Accept header, unsupported ones are rejected before anything else.
+	negotiate := Negotiate(DefaultCodecs)
 
 	// =========================================================================
 	// Construct and attach relevant handlers to web app api
 
-	api.API.HandleFunc("/current", GetCurrentFibonacciNumber(func(ctx context.Context) (int64, error) {
-		return app.CurrentFibonacciNumber(ctx)
-	})).Methods(http.MethodGet)
+	// Requests are limited before being authorized, so ones lacking valid credentials are limited by IP address.
+	guard := func(route string, scope Scope, h stdhttp.Handler) stdhttp.Handler {
+		return Authenticate(auth, limiter.Limit(route, Authorize(auth, scope, h)))
+	}
+
+	// v1 routes are served under /v1 and under the root as deprecated aliases.
+	v1 := func(path string, h stdhttp.Handler) {
+		api.API.Handle("/v1"+path, h).Methods(http.MethodGet)
+		api.API.Handle(path, Deprecate(legacyDeprecatedAt, legacySunset, "/v1"+path)(h)).Methods(http.MethodGet)
+	}
+
+	v1("/current", negotiate(guard("current", ScopeRead, GetCurrentFibonacciNumber(func(ctx context.Context) (int64, error) {
+		return seq.CurrentFibonacciNumber(ctx)
+	}))))
+
+	v1("/next", negotiate(guard("next", ScopeWrite, GetNextFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
+		return seq.NextFibonacciNumber(ctx)
+	}))))
 
-	api.API.HandleFunc("/next", GetNextFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
-		return app.NextFibonacciNumber(ctx)
-	})).Methods(http.MethodGet)
+	v1("/previous", negotiate(guard("previous", ScopeWrite, GetPreviousFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
+		return seq.PreviousFibonacciNumber(ctx)
+	}))))
 
-	api.API.HandleFunc("/previous", GetPreviousFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
-		return app.PreviousFibonacciNumber(ctx)
-	})).Methods(http.MethodGet)
+	// Reset is new in v1, so it has no deprecated alias.
+	api.API.Handle("/v1/reset", negotiate(guard("reset", ScopeWrite, ResetFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
+		return seq.SeekFibonacciNumber(ctx, app.Config().Min)
+	})))).Methods(http.MethodPost)
+
+	// Commands moving the counter are authorized per command, see FibonacciWebSocket.
+	v1("/ws", guard("ws", ScopeRead, FibonacciWebSocket(api.hub, seq, cfg.WebSocket)))
+
+	api.API.Handle("/v2/current", negotiate(guard("current", ScopeRead, GetNumber(numbers.CurrentNumber)))).Methods(http.MethodGet)
+	api.API.Handle("/v2/next", negotiate(guard("next", ScopeWrite, GetNumber(numbers.NextNumber)))).Methods(http.MethodGet)
+	api.API.Handle("/v2/previous", negotiate(guard("previous", ScopeWrite, GetNumber(numbers.PreviousNumber)))).Methods(http.MethodGet)
+
+	// Probes are not versioned.
+	api.API.Handle("/healthz", negotiate(GetHealthz(health))).Methods(http.MethodGet)
+	api.API.Handle("/readyz", negotiate(GetReadyz(health))).Methods(http.MethodGet)
 
 	router := mux.NewRouter()
 
 	// recover from a panic, log, and continue to the next handler
-	router.PathPrefix("/").Handler(handlers.RecoveryHandler()(api.API))
+	router.PathPrefix("/").Handler(handlers.RecoveryHandler(
+		handlers.RecoveryLogger(api.logger),
+		handlers.PrintRecoveryStack(true),
+	)(api.API))
 
 	return router
 }
+
+// chain wraps h with middleware, the first one being the outermost as in mux.Router.Use.
+func chain(h http.Handler, middleware ...mux.MiddlewareFunc) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// injectLogger makes logger available to handlers through request context.
+func injectLogger(logger log.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(log.NewContext(r.Context(), logger)))
+		})
+	}
+}
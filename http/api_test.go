@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	shutdown := make(chan os.Signal, 1)
+	app := NewApp(shutdown, log.Discard())
+
+	calls := 0
+	check := CheckerFunc(func(ctx context.Context) error {
+		if calls++; calls < 3 {
+			return nil
+		}
+		return errors.New("counter invariant does not hold")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	app.VerifyIntegrity(ctx, time.Millisecond, check)
+
+	select {
+	case sig := <-shutdown:
+		if sig != syscall.SIGSTOP {
+			t.Errorf("got %v, want %v", sig, syscall.SIGSTOP)
+		}
+	default:
+		t.Errorf("got no shutdown signal, want %v", syscall.SIGSTOP)
+	}
+
+	if calls != 3 {
+		t.Errorf("got %v checks, want %v", calls, 3)
+	}
+}
+
+func TestAppReload(t *testing.T) {
+	path := writeAPIKeys(t)
+
+	keys, err := NewAPIKeys(path)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var sequence fibonacci.Fibonacci
+	app := NewApp(make(chan os.Signal, 1), log.Discard())
+	handler := API(app, &Config{}, &sequence, NewMetrics(&sequence), NewHealth(), Authenticators{keys})
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.Header.Set(APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	data := `{"keys": [{"name": "rotated", "hash": "` + HashAPIKey("rotated-key") + `", "scopes": ["read"]}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// staged settings are not applied until asked to
+	cfg := &Config{RateLimit: RateLimitConfig{RateLimitBudget: RateLimitBudget{Rate: 1, Burst: 5}}}
+	apply, err := app.StageReload(cfg)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if w := serve("reader-key"); w.Code != http.StatusOK || w.Header().Get(RateLimitLimitHeader) != "" {
+		t.Errorf("got %v %v, want %v %v", w.Code, w.Header().Get(RateLimitLimitHeader), http.StatusOK, "")
+	}
+	apply()
+
+	if w := serve("reader-key"); w.Code != http.StatusUnauthorized {
+		t.Errorf("got %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+	if w := serve("rotated-key"); w.Code != http.StatusOK || w.Header().Get(RateLimitLimitHeader) != "5" {
+		t.Errorf("got %v %v, want %v %v", w.Code, w.Header().Get(RateLimitLimitHeader), http.StatusOK, "5")
+	}
+
+	// invalid keys reject the whole reload keeping rate limits too
+	if err := os.WriteFile(path, []byte(`{"keys": [`), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if err := app.Reload(&Config{}); err == nil {
+		t.Errorf("got %v, want error", err)
+	}
+
+	if w := serve("rotated-key"); w.Code != http.StatusOK || w.Header().Get(RateLimitLimitHeader) != "5" {
+		t.Errorf("got %v %v, want %v %v", w.Code, w.Header().Get(RateLimitLimitHeader), http.StatusOK, "5")
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	var testcases = []struct {
+		path   string
+		accept string
+		term   int // counter position before the request
+
+		statusCode int
+		response   string
+		deprecated bool
+	}{
+		// v1
+		{path: "/v1/current", term: 10, statusCode: http.StatusOK, response: `{"current":55}`},
+		{path: "/v1/next", term: 10, statusCode: http.StatusOK, response: `{"next":89}`},
+		{path: "/v1/previous", term: 10, statusCode: http.StatusOK, response: `{"previous":34}`},
+
+		// legacy aliases of v1
+		{path: "/current", term: 10, statusCode: http.StatusOK, response: `{"current":55}`, deprecated: true},
+		{path: "/next", term: 10, statusCode: http.StatusOK, response: `{"next":89}`, deprecated: true},
+		{path: "/previous", term: 10, statusCode: http.StatusOK, response: `{"previous":34}`, deprecated: true},
+
+		// v2
+		{path: "/v2/current", term: 10, statusCode: http.StatusOK, response: `{"index":10,"value":"55","sequence":"fibonacci"}`},
+		{path: "/v2/next", term: 10, statusCode: http.StatusOK, response: `{"index":11,"value":"89","sequence":"fibonacci"}`},
+		{path: "/v2/previous", term: 10, statusCode: http.StatusOK, response: `{"index":9,"value":"34","sequence":"fibonacci"}`},
+		{path: "/v2/next", term: fibonacci.MaxThTerm - 1, statusCode: http.StatusOK, response: `{"index":92,"value":"7540113804746346429","sequence":"fibonacci"}`},
+		{path: "/v2/current", accept: "text/plain", term: 2, statusCode: http.StatusOK, response: "index: 2\nvalue: 1\nsequence: fibonacci"},
+		{path: "/v2/previous", term: 0, statusCode: http.StatusUnprocessableEntity, response: `{"error":"counter underflow","request_id":"test"}`},
+
+		// unversioned probes and unknown versions
+		{path: "/healthz", statusCode: http.StatusOK, response: `{"status":"ok","checks":{"counter":"ok"}}`},
+		{path: "/v1/healthz", statusCode: http.StatusNotFound, response: "404 page not found"},
+		{path: "/v3/current", statusCode: http.StatusNotFound, response: "404 page not found"},
+	}
+
+	for i, tt := range testcases {
+		sequence := fibonacci.Fibonacci{}
+		if _, err := sequence.SeekFibonacciNumber(context.Background(), tt.term); err != nil {
+			t.Fatal(err)
+		}
+
+		health := NewHealth()
+		health.RegisterLiveness("counter", CheckerFunc(sequence.Verify))
+
+		handler := API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, NewMetrics(&sequence), health, nil)
+
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set(RequestIDHeader, "test")
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.statusCode {
+			t.Errorf("#%d %s HTTP status got %v, want %v", i, tt.path, w.Code, tt.statusCode)
+		}
+		if response := strings.TrimSpace(w.Body.String()); response != tt.response {
+			t.Errorf("#%d %s got %v, want %v", i, tt.path, response, tt.response)
+		}
+		if id := w.Header().Get(RequestIDHeader); id != "test" {
+			t.Errorf("#%d %s %s got %v, want %v", i, tt.path, RequestIDHeader, id, "test")
+		}
+
+		deprecation, sunset, link := w.Header().Get("Deprecation"), w.Header().Get("Sunset"), w.Header().Get("Link")
+		if !tt.deprecated {
+			if deprecation != "" || sunset != "" || link != "" {
+				t.Errorf("#%d %s got deprecation headers %q, %q, %q, want none", i, tt.path, deprecation, sunset, link)
+			}
+			continue
+		}
+
+		if deprecation != "@1792368000" {
+			t.Errorf("#%d %s Deprecation got %v, want %v", i, tt.path, deprecation, "@1792368000")
+		}
+		if sunset != "Mon, 19 Apr 2027 00:00:00 GMT" {
+			t.Errorf("#%d %s Sunset got %v, want %v", i, tt.path, sunset, "Mon, 19 Apr 2027 00:00:00 GMT")
+		}
+		if expected := `</v1` + tt.path + `>; rel="successor-version"`; link != expected {
+			t.Errorf("#%d %s Link got %v, want %v", i, tt.path, link, expected)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	if err := sequence.SetConfig(fibonacci.Config{Min: 5, Max: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sequence.SeekFibonacciNumber(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, NewMetrics(&sequence), NewHealth(), nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/reset", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP status got %v, want %v", w.Code, http.StatusOK)
+	}
+	if response := strings.TrimSpace(w.Body.String()); response != `{"current":5}` {
+		t.Errorf("got %v, want %v", response, `{"current":5}`)
+	}
+	if position := sequence.Position(); position != 5 {
+		t.Errorf("position got %v, want %v", position, 5)
+	}
+}
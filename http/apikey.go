@@ -0,0 +1,106 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// apiKeysFile is format of file API keys are loaded from.
+//
+//	{"keys": [{"name": "ci", "hash": "<hex encoded SHA-256 of the key>", "scopes": ["read", "write"]}]}
+type apiKeysFile struct {
+	Keys []struct {
+		Name   string  `json:"name"`
+		Hash   string  `json:"hash"`
+		Scopes []Scope `json:"scopes"`
+	} `json:"keys"`
+}
+
+// APIKeys authenticates clients by API key sent in APIKeyHeader.
+//
+// Keys are loaded from a file storing only their SHA-256 hashes, so a leaked file does not leak the keys.
+// API keys are expected to be long random strings, a fast hash is sufficient for them.
+// It is safe to use APIKeys concurrently.
+type APIKeys struct {
+	path string
+	keys atomic.Pointer[map[string]*Principal] // principals by key hash
+}
+
+// NewAPIKeys constructs a new APIKeys loading keys from file at path.
+func NewAPIKeys(path string) (*APIKeys, error) {
+	k := &APIKeys{path: path}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload reloads keys from the file, keys loaded previously are kept if it fails.
+func (k *APIKeys) Reload() error {
+	apply, err := k.stage()
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// stage implements stager.
+func (k *APIKeys) stage() (func(), error) {
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading API keys file %s", k.path)
+	}
+
+	var file apiKeysFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrapf(err, "parsing API keys file %s", k.path)
+	}
+
+	keys := make(map[string]*Principal, len(file.Keys))
+	for i, key := range file.Keys {
+		hash := strings.ToLower(key.Hash)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, errors.Newf("API key #%d %q hash is not a hex encoded SHA-256 digest", i, key.Name)
+		}
+		for _, scope := range key.Scopes {
+			if !scope.valid() {
+				return nil, errors.Newf("API key #%d %q has unknown scope %q", i, key.Name, scope)
+			}
+		}
+		if _, ok := keys[hash]; ok {
+			return nil, errors.Newf("API key #%d %q is duplicated", i, key.Name)
+		}
+
+		keys[hash] = &Principal{ID: "apikey:" + key.Name, Name: key.Name, Scopes: key.Scopes}
+	}
+
+	return func() { k.keys.Store(&keys) }, nil
+}
+
+// Authenticate implements Authenticator.
+func (k *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	principal, ok := (*k.keys.Load())[HashAPIKey(key)]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return principal, nil
+}
+
+// HashAPIKey returns hex encoded SHA-256 hash of key as stored in API keys file.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// Scope is a permission granted to an authenticated client.
+type Scope string
+
+// Supported scopes.
+const (
+	ScopeRead  Scope = "read"  // read the counter without moving it
+	ScopeWrite Scope = "write" // move the counter
+	ScopeAdmin Scope = "admin" // use administrative endpoints, such as profiling
+)
+
+// valid reports whether s is one of supported scopes.
+func (s Scope) valid() bool {
+	return s == ScopeRead || s == ScopeWrite || s == ScopeAdmin
+}
+
+// Authentication and authorization errors.
+var (
+	// ErrMissingCredentials is returned by Authenticator when request carries no credentials it understands.
+	ErrMissingCredentials = errors.New("missing credentials")
+
+	// ErrInvalidCredentials is returned by Authenticator when request carries credentials it rejects.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	errInsufficientScope = errors.New("insufficient scope")
+)
+
+// Principal is an authenticated client.
+type Principal struct {
+	ID     string  // identifies the client uniquely among all authenticators, such as apikey:name
+	Name   string  // name identifying the client in logs
+	Scopes []Scope // scopes granted to the client
+}
+
+// HasScope reports whether scope is granted to p.
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator authenticates clients making requests.
+type Authenticator interface {
+	// Authenticate returns principal identified by credentials r carries.
+	// ErrMissingCredentials is returned when there are none, ErrInvalidCredentials when they are rejected.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Authenticators is a chain of authenticators, the first one finding credentials it understands decides.
+type Authenticators []Authenticator
+
+// Authenticate implements Authenticator.
+func (a Authenticators) Authenticate(r *http.Request) (*Principal, error) {
+	for _, auth := range a {
+		principal, err := auth.Authenticate(r)
+		if errors.Is(err, ErrMissingCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrMissingCredentials
+}
+
+// Reload reloads credentials of every authenticator in a loading them from files.
+// Credentials are replaced only once all of them are loaded, otherwise all failures are reported
+// and credentials loaded previously are kept by every authenticator.
+func (a Authenticators) Reload() error {
+	apply, err := a.stage()
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// stage implements stager.
+func (a Authenticators) stage() (func(), error) {
+	var applies []func()
+	var errs []error
+	for _, auth := range a {
+		s, ok := auth.(stager)
+		if !ok {
+			continue
+		}
+
+		apply, err := s.stage()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		applies = append(applies, apply)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		for _, apply := range applies {
+			apply()
+		}
+	}, nil
+}
+
+// stager is implemented by components able to load their state at runtime without replacing the current one,
+// so state of several components is replaced only once all of them loaded it.
+type stager interface {
+	// stage loads the state returning function replacing the current one with it.
+	stage() (apply func(), err error)
+}
+
+// BearerToken authenticates clients presenting a fixed token as bearer token in Authorization header.
+// Other bearer tokens are reported as missing credentials, so authenticators following it, such as JWT, get them.
+type BearerToken struct {
+	hash      [sha256.Size]byte
+	principal *Principal
+}
+
+// NewBearerToken constructs a new BearerToken granting scopes to principal called name presenting token.
+func NewBearerToken(name, token string, scopes ...Scope) *BearerToken {
+	return &BearerToken{
+		hash:      sha256.Sum256([]byte(token)),
+		principal: &Principal{ID: "token:" + name, Name: name, Scopes: scopes},
+	}
+}
+
+// Authenticate implements Authenticator.
+func (t *BearerToken) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrMissingCredentials
+	}
+
+	// hashes have equal length, so comparison time does not reveal token length either
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	if subtle.ConstantTimeCompare(hash[:], t.hash[:]) != 1 {
+		return nil, ErrMissingCredentials
+	}
+	return t.principal, nil
+}
+
+// principalKey is context key of Principal.
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying p.
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns Principal carried by ctx or nil if request was not authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// authResult is outcome of authenticating a request by Authenticate.
+type authResult struct {
+	principal *Principal
+	err       error
+}
+
+// authResultKey is context key of authResult.
+type authResultKey struct{}
+
+// Authenticate authenticates requests by auth passing them to next with principal carried by request context
+// when they are authenticated. Requests are never rejected, so handlers in between, such as rate limiting, see
+// requests lacking valid credentials too, Authorize following it rejects them without authenticating them again.
+// When auth is nil authentication is disabled and next is returned as is.
+func Authenticate(auth Authenticator, next http.Handler) http.Handler {
+	if auth == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.Authenticate(r)
+
+		ctx := context.WithValue(r.Context(), authResultKey{}, authResult{principal: principal, err: err})
+		if err == nil {
+			ctx = ContextWithPrincipal(ctx, principal)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authorize lets through to next only requests authenticated by auth having scope granted.
+// Unauthenticated requests are rejected with 401 Unauthorized, requests lacking scope with 403 Forbidden.
+// Requests already authenticated by Authenticate are not authenticated again.
+// When auth is nil authentication is disabled and next is returned as is.
+func Authorize(auth Authenticator, scope Scope, next http.Handler) http.Handler {
+	if auth == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := r.Context().Value(authResultKey{}).(authResult)
+		if !ok {
+			result.principal, result.err = auth.Authenticate(r)
+		}
+
+		principal, err := result.principal, result.err
+		if err != nil {
+			if !errors.Is(err, ErrMissingCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+				log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+					"handler": "auth",
+					"method":  "Authorize",
+				}).Println("unable to authenticate request")
+			}
+
+			respondError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			log.WithContext(r.Context()).WithError(errInsufficientScope).WithFields(log.Fields{
+				"handler":   "auth",
+				"method":    "Authorize",
+				"principal": principal.Name,
+				"scope":     scope,
+			}).Debug("request forbidden")
+
+			respondError(w, r, http.StatusForbidden, "forbidden")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+	})
+}
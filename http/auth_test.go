@@ -0,0 +1,178 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+// writeAPIKeys writes API keys file granting reader key read scope and writer key both read and write scopes.
+func writeAPIKeys(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := `{"keys": [
+		{"name": "reader", "hash": "` + HashAPIKey("reader-key") + `", "scopes": ["read"]},
+		{"name": "writer", "hash": "` + strings.ToUpper(HashAPIKey("writer-key")) + `", "scopes": ["read", "write"]}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	return path
+}
+
+func TestAuthorize(t *testing.T) {
+	keys, err := NewAPIKeys(writeAPIKeys(t))
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var sequence fibonacci.Fibonacci
+	handler := API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, NewMetrics(&sequence), NewHealth(), keys)
+
+	var testcases = []struct {
+		method string // GET when empty
+		path   string
+		key    string
+
+		code    int
+		message string
+	}{
+		{path: "/current", code: http.StatusUnauthorized, message: "unauthorized"},
+		{path: "/current", key: "unknown-key", code: http.StatusUnauthorized, message: "unauthorized"},
+		{path: "/current", key: "reader-key", code: http.StatusOK},
+		{path: "/next", key: "reader-key", code: http.StatusForbidden, message: "forbidden"},
+		{path: "/previous", key: "reader-key", code: http.StatusForbidden, message: "forbidden"},
+		{path: "/next", key: "writer-key", code: http.StatusOK},
+		{method: http.MethodPost, path: "/v1/reset", code: http.StatusUnauthorized, message: "unauthorized"},
+		{method: http.MethodPost, path: "/v1/reset", key: "reader-key", code: http.StatusForbidden, message: "forbidden"},
+		{method: http.MethodPost, path: "/v1/reset", key: "writer-key", code: http.StatusOK},
+		{path: "/healthz", code: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		method := tt.method
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		r := httptest.NewRequest(method, tt.path, nil)
+		if tt.key != "" {
+			r.Header.Set(APIKeyHeader, tt.key)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.code {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, tt.code)
+		}
+		if tt.message == "" {
+			continue
+		}
+
+		var body api.Error
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if body.Message != tt.message || body.RequestID == "" {
+			t.Errorf("#%d body got %v, want %v with request ID", i, body, tt.message)
+		}
+	}
+}
+
+func TestAPIKeysReload(t *testing.T) {
+	path := writeAPIKeys(t)
+
+	keys, err := NewAPIKeys(path)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var testcases = []string{
+		`{"keys": [{"name": "plain", "hash": "plain-key", "scopes": ["read"]}]}`,
+		`{"keys": [{"name": "admin", "hash": "` + HashAPIKey("admin-key") + `", "scopes": ["root"]}]}`,
+		`{"keys": [`,
+	}
+
+	for i, data := range testcases {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if err := keys.Reload(); err == nil {
+			t.Errorf("#%d got %v, want error", i, err)
+		}
+
+		// previously loaded keys are kept
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.Header.Set(APIKeyHeader, "reader-key")
+		if principal, err := keys.Authenticate(r); err != nil || principal.Name != "reader" {
+			t.Errorf("#%d got %v, %v, want %v", i, principal, err, "reader")
+		}
+	}
+}
+
+func TestAuthenticatorsReload(t *testing.T) {
+	keysPath := writeAPIKeys(t)
+	keys, err := NewAPIKeys(keysPath)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]crypto.Signer{"current": key})
+	jwt, err := NewJWT(JWTConfig{JWKSFile: jwksPath, Issuer: "gateway", Audience: "fibonacci"})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	auths := Authenticators{keys, jwt}
+
+	// API keys are rotated while JWKS file is broken
+	data := `{"keys": [{"name": "rotated", "hash": "` + HashAPIKey("rotated-key") + `", "scopes": ["read"]}]}`
+	if err := os.WriteFile(keysPath, []byte(data), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if err := os.WriteFile(jwksPath, []byte(`{"keys": [`), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if err := auths.Reload(); err == nil {
+		t.Errorf("got %v, want error", err)
+	}
+
+	// none of the credentials are replaced
+	var testcases = []struct {
+		key string
+
+		err error
+	}{
+		{key: "reader-key"},
+		{key: "rotated-key", err: ErrInvalidCredentials},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.Header.Set(APIKeyHeader, tt.key)
+		if _, err := auths.Authenticate(r); !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+	}
+}
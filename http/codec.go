@@ -0,0 +1,318 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+
+	"github.com/gorilla/mux"
+)
+
+// Codec encodes response payloads in a media type.
+type Codec interface {
+	// MediaTypes returns media types codec is negotiated by, the first one is the preferred one.
+	MediaTypes() []string
+
+	// ContentType returns value of Content-Type header of encoded payloads.
+	ContentType() string
+
+	// Encode writes v encoded to w.
+	Encode(w io.Writer, v any) error
+}
+
+// Codecs is a registry of codecs response payloads are encoded by, codec is negotiated by Accept request header.
+// Codec registered first is used when the request accepts any media type.
+// It is safe to use Codecs concurrently.
+type Codecs struct {
+	mu     sync.RWMutex
+	codecs []Codec
+}
+
+// NewCodecs constructs a new Codecs with codecs registered.
+func NewCodecs(codecs ...Codec) *Codecs {
+	c := &Codecs{}
+	for _, codec := range codecs {
+		c.Register(codec)
+	}
+	return c
+}
+
+// DefaultCodecs are codecs responses of pkg/api/v1 types are encoded by, JSON is the default one.
+var DefaultCodecs = NewCodecs(JSONCodec{}, TextCodec{}, XMLCodec{}, CBORCodec{}, MessagePackCodec{}, ProtobufCodec{})
+
+// Register registers codec, it replaces codec registered earlier for the same preferred media type.
+func (c *Codecs) Register(codec Codec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, registered := range c.codecs {
+		if registered.MediaTypes()[0] == codec.MediaTypes()[0] {
+			c.codecs[i] = codec
+			return
+		}
+	}
+	c.codecs = append(c.codecs, codec)
+}
+
+// Negotiate returns codec of the most preferred media type accepted by accept, value of Accept request header
+// as defined by RFC 9110. Codec registered first is returned when accept is empty. False is returned when
+// no codec is acceptable.
+func (c *Codecs) Negotiate(accept string) (Codec, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.codecs) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return c.codecs[0], true
+	}
+
+	ranges := parseAccept(accept)
+	for _, rng := range ranges {
+		if rng.q == 0 {
+			continue
+		}
+
+		for _, codec := range c.codecs {
+			for _, mediaType := range codec.MediaTypes() {
+				if rng.matches(mediaType) && !excluded(ranges, mediaType) {
+					return codec, true
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// mediaRange is a media range of Accept request header.
+type mediaRange struct {
+	mediaType string  // type/subtype, either may be *
+	q         float64 // relative weight, 0 means not acceptable
+}
+
+// specificity ranks r: */* is the least specific, type/* is more specific and type/subtype is the most specific.
+func (r mediaRange) specificity() int {
+	switch {
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether r matches mediaType.
+func (r mediaRange) matches(mediaType string) bool {
+	switch r.specificity() {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*"))
+	default:
+		return r.mediaType == mediaType
+	}
+}
+
+// excluded reports whether mediaType is explicitly made not acceptable by ranges, that is whether the most
+// specific range matching it has zero weight. More specific ranges override less specific ones, so
+// text/*;q=0 excludes text/plain unless it is accepted by text/plain range.
+func excluded(ranges []mediaRange, mediaType string) bool {
+	specificity, q := -1, 0.0
+	for _, rng := range ranges {
+		if rng.matches(mediaType) && rng.specificity() > specificity {
+			specificity, q = rng.specificity(), rng.q
+		}
+	}
+	return specificity >= 0 && q == 0
+}
+
+// parseAccept parses media ranges of accept sorting them by weight, more specific ones first on equal weights.
+// Invalid media ranges are ignored.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+
+		q := 1.0
+		if weight, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(weight, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+// codecKey is the context key codec negotiated for the request is stored under.
+type codecKey struct{}
+
+// Negotiate negotiates codec response payloads are encoded by Respond among codecs by Accept request header.
+// Requests accepting none of the media types are rejected with 406 Not Acceptable before reaching next.
+func Negotiate(codecs *Codecs) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+
+			codec, ok := codecs.Negotiate(r.Header.Get("Accept"))
+			if !ok {
+				respondError(w, r, http.StatusNotAcceptable, "none of accepted media types is supported")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), codecKey{}, codec)))
+		})
+	}
+}
+
+// Respond responds with status and v encoded by codec negotiated for r by Negotiate, JSON when there is none.
+// Payload is encoded before anything is written, so when v can not be encoded the client gets
+// 500 Internal Server Error instead of a truncated payload and the error is returned.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	codec, ok := r.Context().Value(codecKey{}).(Codec)
+	if !ok {
+		codec = JSONCodec{}
+	}
+
+	var buf bytes.Buffer
+	err := codec.Encode(&buf, v)
+	if err != nil {
+		err = errors.Wrapf(err, "encoding response as %s", codec.MediaTypes()[0])
+		status = http.StatusInternalServerError
+
+		// error envelope is encodable by every codec, JSON is the last resort nevertheless
+		internal := &api.Error{Message: "internal error", RequestID: log.RequestIDFromContext(r.Context())}
+		if buf.Reset(); codec.Encode(&buf, internal) != nil {
+			codec = JSONCodec{}
+			buf.Reset()
+			codec.Encode(&buf, internal)
+		}
+	}
+
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.WriteHeader(status)
+	if _, werr := buf.WriteTo(w); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// respondError responds with status and message wrapped in the standard error envelope.
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if err := Respond(w, r, status, &api.Error{
+		Message:   message,
+		RequestID: log.RequestIDFromContext(r.Context()),
+	}); err != nil {
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "http",
+			"method":  "respondError",
+		}).Println("unable to marshal response data")
+	}
+}
+
+// field is a field of response payload encoded by codecs other than JSON.
+type field struct {
+	name  string        // name given by json tag
+	num   int           // protobuf field number given by proto tag, 0 when there is none
+	value reflect.Value // string, bool, integer or map of strings to them
+}
+
+// fields returns fields of struct v, or pointer to it, in order of their declaration.
+// Fields are named and omitted by json tags, like encoding/json does.
+func fields(v any) ([]field, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, errors.New("payload is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.Newf("payload of type %s is not a struct", rv.Type())
+	}
+
+	var fs []field
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmpty(rv.Field(i)) {
+			continue
+		}
+
+		if !encodable(sf.Type) {
+			return nil, errors.Newf("field %s of type %s can not be encoded", sf.Name, sf.Type)
+		}
+
+		num, _ := strconv.Atoi(sf.Tag.Get("proto"))
+		fs = append(fs, field{name: name, num: num, value: rv.Field(i)})
+	}
+
+	return fs, nil
+}
+
+// isEmpty reports whether v is empty value omitted by omitempty option of json tag.
+func isEmpty(v reflect.Value) bool {
+	if v.Kind() == reflect.Map {
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// encodable reports whether values of t are supported by codecs other than JSON.
+func encodable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() != reflect.Map && encodable(t.Elem())
+	default:
+		return false
+	}
+}
+
+// sortedKeys returns keys of map m sorted, so encoded payloads are deterministic.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
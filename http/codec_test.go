@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCodecsNegotiate(t *testing.T) {
+	var testcases = []struct {
+		accept string
+
+		mediaType string // preferred media type of the codec negotiated, empty when none is acceptable
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/plain", "text/plain"},
+		{"text/*", "text/plain"},
+		{"text/xml", "application/xml"},
+		{"application/xml;q=0.5, application/cbor", "application/cbor"},
+		{"application/x-msgpack", "application/msgpack"},
+		{"application/x-protobuf;q=0.9, text/xml;q=0.8", "application/protobuf"},
+		{"application/json;q=0, */*", "text/plain"},
+		{"application/*;q=0, */*", "text/plain"},
+		{"application/*;q=0, text/*;q=0.5", "text/plain"},
+		{"text/*;q=0, text/plain;q=0.5", "text/plain"},
+		{"text/*;q=0, text/plain", "text/plain"},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+		{"text/*;q=0, application/*;q=0, */*", ""},
+		{"text/*;q=0, text/html", ""},
+		{"garbage", ""},
+	}
+
+	for i, tt := range testcases {
+		codec, ok := DefaultCodecs.Negotiate(tt.accept)
+		if !ok {
+			if tt.mediaType != "" {
+				t.Errorf("#%d got none, want %v", i, tt.mediaType)
+			}
+			continue
+		}
+
+		if mediaType := codec.MediaTypes()[0]; mediaType != tt.mediaType {
+			t.Errorf("#%d got %v, want %v", i, mediaType, tt.mediaType)
+		}
+	}
+}
+
+func TestCodecsEncode(t *testing.T) {
+	payload := struct {
+		Name   string            `json:"name" proto:"1"`
+		Number int64             `json:"number" proto:"2"`
+		Checks map[string]string `json:"checks,omitempty" proto:"3"`
+		Empty  string            `json:"empty,omitempty" proto:"4"`
+	}{
+		Name:   "a",
+		Number: -1000,
+		Checks: map[string]string{"b": "c"},
+	}
+
+	var testcases = []struct {
+		codec Codec
+
+		encoded string
+	}{
+		{JSONCodec{}, `{"name":"a","number":-1000,"checks":{"b":"c"}}` + "\n"},
+		{TextCodec{}, "name: a\nnumber: -1000\nchecks.b: c\n"},
+		{XMLCodec{}, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><name>a</name><number>-1000</number><checks><entry key="b">c</entry></checks></response>`},
+		{CBORCodec{}, "\xa3\x64name\x61a\x66number\x39\x03\xe7\x66checks\xa1\x61b\x61c"},
+		{MessagePackCodec{}, "\x83\xa4name\xa1a\xa6number\xd1\xfc\x18\xa6checks\x81\xa1b\xa1c"},
+		{ProtobufCodec{}, "\x0a\x01a\x10\x98\xf8\xff\xff\xff\xff\xff\xff\xff\x01\x1a\x06\x0a\x01b\x12\x01c"},
+	}
+
+	for i, tt := range testcases {
+		var buf bytes.Buffer
+		if err := tt.codec.Encode(&buf, &payload); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+			continue
+		}
+
+		if encoded := buf.String(); encoded != tt.encoded {
+			t.Errorf("#%d got %q, want %q", i, encoded, tt.encoded)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	var testcases = []struct {
+		accept string
+
+		statusCode  int
+		contentType string
+		response    string
+		called      bool
+	}{
+		{"", http.StatusOK, "application/json", `{"current":1}` + "\n", true},
+		{"text/plain", http.StatusOK, "text/plain; charset=utf-8", "current: 1\n", true},
+		{"image/png", http.StatusNotAcceptable, "application/json", `{"error":"none of accepted media types is supported"}` + "\n", false},
+	}
+
+	for i, tt := range testcases {
+		called := false
+		handler := Negotiate(DefaultCodecs)(GetCurrentFibonacciNumber(func(ctx context.Context) (int64, error) {
+			called = true
+			return 1, nil
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/current", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if statusCode := w.Result().StatusCode; statusCode != tt.statusCode {
+			t.Errorf("#%d HTTP status got %v, want %v", i, statusCode, tt.statusCode)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != tt.contentType {
+			t.Errorf("#%d Content-Type got %v, want %v", i, contentType, tt.contentType)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("#%d Vary got %v, want %v", i, vary, "Accept")
+		}
+		if response := w.Body.String(); response != tt.response {
+			t.Errorf("#%d got %q, want %q", i, response, tt.response)
+		}
+		if called != tt.called {
+			t.Errorf("#%d handler called got %v, want %v", i, called, tt.called)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	payload := struct {
+		Terms []int64 `json:"terms"`
+	}{
+		Terms: []int64{0, 1},
+	}
+
+	var testcases = []struct {
+		accept string
+
+		statusCode  int
+		contentType string
+		response    string
+		err         bool
+	}{
+		{"application/json", http.StatusOK, "application/json", `{"terms":[0,1]}` + "\n", false},
+		{"text/plain", http.StatusInternalServerError, "text/plain; charset=utf-8", "error: internal error\n", true},
+	}
+
+	for i, tt := range testcases {
+		var err error
+		handler := Negotiate(DefaultCodecs)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err = Respond(w, r, http.StatusOK, &payload)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/terms", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+		}
+		if statusCode := w.Result().StatusCode; statusCode != tt.statusCode {
+			t.Errorf("#%d HTTP status got %v, want %v", i, statusCode, tt.statusCode)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != tt.contentType {
+			t.Errorf("#%d Content-Type got %v, want %v", i, contentType, tt.contentType)
+		}
+		if response := w.Body.String(); response != tt.response {
+			t.Errorf("#%d got %q, want %q", i, response, tt.response)
+		}
+	}
+}
@@ -1,6 +1,218 @@
 package http
 
+import (
+	"math"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
 // Config represents HTTP server configuration.
 type Config struct {
 	Address string `mapstructure:"address"` // HTTP server address
+
+	ReadTimeout       time.Duration `mapstructure:"readtimeout"`       // maximum duration of reading entire request
+	ReadHeaderTimeout time.Duration `mapstructure:"readheadertimeout"` // maximum duration of reading request headers
+	WriteTimeout      time.Duration `mapstructure:"writetimeout"`      // maximum duration of writing response
+	IdleTimeout       time.Duration `mapstructure:"idletimeout"`       // maximum duration of keep-alive connection waiting for next request
+	MaxHeaderBytes    int           `mapstructure:"maxheaderbytes"`    // maximum size of request headers
+	ShutdownTimeout   time.Duration `mapstructure:"shutdowntimeout"`   // maximum duration of draining connections on shutdown
+	DrainDelay        time.Duration `mapstructure:"draindelay"`        // duration of serving after readiness probe starts failing on shutdown
+
+	WebSocket WebSocketConfig `mapstructure:"websocket"` // WebSocket interface config
+	AccessLog AccessLogConfig `mapstructure:"accesslog"` // Access log config
+	RateLimit RateLimitConfig `mapstructure:"ratelimit"` // Rate limiting config
+	Auth      AuthConfig      `mapstructure:"auth"`      // Authentication config
+	TLS       TLSConfig       `mapstructure:"tls"`       // TLS config
+}
+
+// Default HTTP server configuration values.
+const (
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultMaxHeaderBytes    = 1 << 20
+	DefaultShutdownTimeout   = 5 * time.Second
+)
+
+// Validate checks whether settings of c are sane and returns all problems found.
+// Zero timeouts disable them as in http.Server, shutdown timeout is required though.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Address == "" {
+		errs = append(errs, errors.New("http address must be set"))
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read timeout", c.ReadTimeout},
+		{"read header timeout", c.ReadHeaderTimeout},
+		{"write timeout", c.WriteTimeout},
+		{"idle timeout", c.IdleTimeout},
+	} {
+		if timeout.value < 0 {
+			errs = append(errs, errors.Newf("http %s %s must not be negative", timeout.name, timeout.value))
+		}
+	}
+
+	if c.ReadTimeout > 0 && c.ReadHeaderTimeout > c.ReadTimeout {
+		errs = append(errs, errors.Newf("http read header timeout %s must not exceed read timeout %s", c.ReadHeaderTimeout, c.ReadTimeout))
+	}
+	if c.MaxHeaderBytes < 0 {
+		errs = append(errs, errors.Newf("http max header bytes %d must not be negative", c.MaxHeaderBytes))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.Newf("http shutdown timeout %s must be positive", c.ShutdownTimeout))
+	}
+	if c.DrainDelay < 0 {
+		errs = append(errs, errors.Newf("http drain delay %s must not be negative", c.DrainDelay))
+	}
+
+	if c.WebSocket.RateLimit < 0 || c.WebSocket.Burst < 0 || c.WebSocket.PingInterval < 0 {
+		errs = append(errs, errors.New("http websocket rate limit, burst and ping interval must not be negative"))
+	}
+
+	if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 {
+		errs = append(errs, errors.Newf("http access log sample rate %v must be within [0, 1]", c.AccessLog.SampleRate))
+	}
+	switch c.AccessLog.Format {
+	case "", log.FormatText, log.FormatJSON, log.FormatLogfmt:
+	default:
+		errs = append(errs, errors.Newf("http access log format %q is unknown", c.AccessLog.Format))
+	}
+
+	if c.RateLimit.Rate < 0 || c.RateLimit.Burst < 0 {
+		errs = append(errs, errors.New("http rate limit rate and burst must not be negative"))
+	}
+	for route, budget := range c.RateLimit.Routes {
+		if budget.Rate < 0 || budget.Burst < 0 {
+			errs = append(errs, errors.Newf("http rate limit rate and burst of route %s must not be negative", route))
+		}
+	}
+
+	if c.Auth.JWT.JWKSFile != "" && (c.Auth.JWT.Issuer == "" || c.Auth.JWT.Audience == "") {
+		errs = append(errs, errors.New("http JWT issuer and audience must be set when JWKS file is set"))
+	}
+	if c.Auth.JWT.Leeway < 0 {
+		errs = append(errs, errors.Newf("http JWT leeway %s must not be negative", c.Auth.JWT.Leeway))
+	}
+
+	if c.TLS.Enabled() && (c.TLS.Cert == "" || c.TLS.Key == "") {
+		errs = append(errs, errors.New("http TLS certificate and key must be set together"))
+	}
+	if c.TLS.ClientCA != "" && !c.TLS.Enabled() {
+		errs = append(errs, errors.New("http TLS client CA requires TLS certificate and key to be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// TLSConfig represents TLS configuration.
+type TLSConfig struct {
+	Cert     string `mapstructure:"cert"`     // path of PEM encoded certificate chain, HTTPS is disabled when empty
+	Key      string `mapstructure:"key"`      // path of PEM encoded certificate private key
+	ClientCA string `mapstructure:"clientca"` // path of PEM encoded CA certificates, client certificates are required when set
+}
+
+// Enabled reports whether TLS is configured.
+func (c TLSConfig) Enabled() bool {
+	return c.Cert != "" || c.Key != ""
+}
+
+// AuthConfig represents authentication configuration.
+// Authentication is disabled unless either API keys file or JWKS file is configured.
+type AuthConfig struct {
+	KeysFile string    `mapstructure:"keysfile"` // path of API keys file, API key authentication is disabled when empty
+	JWT      JWTConfig `mapstructure:"jwt"`      // JWT bearer token authentication config
+}
+
+// JWTConfig represents JWT bearer token authentication configuration.
+type JWTConfig struct {
+	JWKSFile   string        `mapstructure:"jwksfile"`   // path of JWKS file, JWT authentication is disabled when empty
+	Issuer     string        `mapstructure:"issuer"`     // required token issuer
+	Audience   string        `mapstructure:"audience"`   // required token audience
+	ScopeClaim string        `mapstructure:"scopeclaim"` // claim scopes are read from, scope by default
+	Leeway     time.Duration `mapstructure:"leeway"`     // clock skew tolerated validating expiry and not before time
+}
+
+// RateLimitConfig represents rate limiting configuration.
+type RateLimitConfig struct {
+	RateLimitBudget `mapstructure:",squash"` // default budget of every limited route
+
+	Routes map[string]RateLimitBudget `mapstructure:"routes"` // budgets by route name overriding default one
+}
+
+// RateLimitBudget represents budget of requests a single client is allowed to make to a route.
+type RateLimitBudget struct {
+	Rate  float64 `mapstructure:"rate"`  // requests per second, route is not limited when zero
+	Burst int     `mapstructure:"burst"` // requests allowed to exceed Rate at once, Rate rounded up when zero
+}
+
+// budget returns budget of route, zero route budget values are replaced with default ones.
+func (c RateLimitConfig) budget(route string) RateLimitBudget {
+	budget := c.Routes[route]
+	if budget.Rate <= 0 {
+		budget.Rate = c.Rate
+	}
+	if budget.Burst <= 0 {
+		budget.Burst = c.Burst
+	}
+	if budget.Burst <= 0 {
+		budget.Burst = int(math.Ceil(budget.Rate))
+	}
+	return budget
+}
+
+// AccessLogConfig represents access log configuration.
+type AccessLogConfig struct {
+	SampleRate float64 `mapstructure:"samplerate"` // fraction of successful requests logged, none when zero, failed ones are always logged
+	Format     string  `mapstructure:"format"`     // access log entries format: text or json
+}
+
+// AdminConfig represents administrative HTTP server configuration.
+type AdminConfig struct {
+	Address    string `mapstructure:"address"`             // Admin HTTP server address, listener is disabled when empty
+	Token      string `mapstructure:"token" secret:"true"` // bearer token granting admin scope
+	ProfileDir string `mapstructure:"profiledir"`          // directory captured profiles are written to, capture is disabled when empty
+}
+
+// IntegrityConfig represents integrity verification configuration.
+type IntegrityConfig struct {
+	Interval time.Duration `mapstructure:"interval"` // interval between integrity checks
+}
+
+// DefaultIntegrityInterval is used when integrity verification interval is not configured.
+const DefaultIntegrityInterval = 10 * time.Second
+
+// WebSocketConfig represents WebSocket stepping interface configuration.
+type WebSocketConfig struct {
+	RateLimit    float64       `mapstructure:"ratelimit"`    // commands per second allowed for a single connection
+	Burst        int           `mapstructure:"burst"`        // commands allowed to exceed RateLimit at once
+	PingInterval time.Duration `mapstructure:"pinginterval"` // interval between keepalive pings
+}
+
+// Default WebSocket interface configuration values.
+const (
+	DefaultWebSocketRateLimit    = 10
+	DefaultWebSocketBurst        = 20
+	DefaultWebSocketPingInterval = 30 * time.Second
+)
+
+// withDefaults returns a copy of c having zero values replaced with defaults.
+func (c WebSocketConfig) withDefaults() WebSocketConfig {
+	if c.RateLimit <= 0 {
+		c.RateLimit = DefaultWebSocketRateLimit
+	}
+	if c.Burst <= 0 {
+		c.Burst = DefaultWebSocketBurst
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = DefaultWebSocketPingInterval
+	}
+	return c
 }
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		Address:           ":8000",
+		ReadTimeout:       DefaultReadTimeout,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		MaxHeaderBytes:    DefaultMaxHeaderBytes,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+	}
+
+	var testcases = []struct {
+		modify func(c *Config)
+
+		err bool
+	}{
+		{modify: func(c *Config) {}},
+		{modify: func(c *Config) { c.ReadTimeout, c.WriteTimeout, c.IdleTimeout = 0, 0, 0 }},
+		{modify: func(c *Config) { c.ReadTimeout = -time.Second }, err: true},
+		{modify: func(c *Config) { c.WriteTimeout = -time.Second }, err: true},
+		{modify: func(c *Config) { c.ReadHeaderTimeout = time.Minute }, err: true},
+		{modify: func(c *Config) { c.ReadTimeout, c.ReadHeaderTimeout = 0, time.Minute }},
+		{modify: func(c *Config) { c.MaxHeaderBytes = -1 }, err: true},
+		{modify: func(c *Config) { c.ShutdownTimeout = 0 }, err: true},
+		{modify: func(c *Config) { c.Address = "" }, err: true},
+		{modify: func(c *Config) { c.AccessLog.SampleRate = 2 }, err: true},
+		{modify: func(c *Config) { c.RateLimit.Routes = map[string]RateLimitBudget{"next": {Rate: -1}} }, err: true},
+		{modify: func(c *Config) { c.Auth.JWT.JWKSFile = "jwks.json" }, err: true},
+		{modify: func(c *Config) { c.TLS.Cert = "server.crt" }, err: true},
+		{modify: func(c *Config) { c.TLS.ClientCA = "ca.crt" }, err: true},
+	}
+
+	for i, tt := range testcases {
+		cfg := valid
+		tt.modify(&cfg)
+
+		if err := cfg.Validate(); (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestConfigValidateReportsAll(t *testing.T) {
+	cfg := Config{ReadTimeout: -time.Second, MaxHeaderBytes: -1}
+
+	err := cfg.Validate()
+	for _, expected := range []string{"address", "read timeout", "max header bytes", "shutdown timeout"} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("got %v, want %v", err, expected)
+		}
+	}
+}
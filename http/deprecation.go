@@ -0,0 +1,33 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Legacy unversioned routes are deprecated aliases of v1 routes, they are going to be removed at sunset.
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacySunset       = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// Deprecate marks responses of route as deprecated since deprecatedAt by Deprecation header of RFC 9745 and
+// announces its removal at sunset by Sunset header of RFC 8594. Link header points clients to successor route.
+func Deprecate(deprecatedAt, sunset time.Time, successor string) mux.MiddlewareFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+	link := "<" + successor + `>; rel="successor-version"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetAt)
+			w.Header().Add("Link", link)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// JSONCodec encodes payloads as JSON using their json tags.
+type JSONCodec struct{}
+
+// MediaTypes implements Codec.
+func (JSONCodec) MediaTypes() []string { return []string{"application/json"} }
+
+// ContentType implements Codec.
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Encode implements Codec.
+func (JSONCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// TextCodec encodes payloads as plain text, a "name: value" line per field.
+// Maps are flattened into a line per key named "name.key", sorted by keys.
+type TextCodec struct{}
+
+// MediaTypes implements Codec.
+func (TextCodec) MediaTypes() []string { return []string{"text/plain"} }
+
+// ContentType implements Codec.
+func (TextCodec) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Encode implements Codec.
+func (TextCodec) Encode(w io.Writer, v any) error {
+	fs, err := fields(v)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, f := range fs {
+		if f.value.Kind() != reflect.Map {
+			fmt.Fprintf(bw, "%s: %v\n", f.name, f.value)
+			continue
+		}
+		for _, key := range sortedKeys(f.value) {
+			fmt.Fprintf(bw, "%s.%s: %v\n", f.name, key, f.value.MapIndex(key))
+		}
+	}
+	return bw.Flush()
+}
+
+// XMLCodec encodes payloads as XML document with response root element and an element per field.
+// Maps are encoded as entry elements with key attribute, sorted by keys.
+type XMLCodec struct{}
+
+// MediaTypes implements Codec.
+func (XMLCodec) MediaTypes() []string { return []string{"application/xml", "text/xml"} }
+
+// ContentType implements Codec.
+func (XMLCodec) ContentType() string { return "application/xml; charset=utf-8" }
+
+// Encode implements Codec.
+func (XMLCodec) Encode(w io.Writer, v any) error {
+	fs, err := fields(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	root := xml.StartElement{Name: xml.Name{Local: "response"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	for _, f := range fs {
+		start := xml.StartElement{Name: xml.Name{Local: f.name}}
+		if f.value.Kind() != reflect.Map {
+			if err := enc.EncodeElement(f.value.Interface(), start); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(f.value) {
+			entry := xml.StartElement{
+				Name: xml.Name{Local: "entry"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key.String()}},
+			}
+			if err := enc.EncodeElement(f.value.MapIndex(key).Interface(), entry); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(start.End()); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// CBORCodec encodes payloads as CBOR, defined by RFC 8949, map keyed by field names.
+// Integers are encoded in their shortest form and map keys are sorted.
+type CBORCodec struct{}
+
+// MediaTypes implements Codec.
+func (CBORCodec) MediaTypes() []string { return []string{"application/cbor"} }
+
+// ContentType implements Codec.
+func (CBORCodec) ContentType() string { return "application/cbor" }
+
+// Encode implements Codec.
+func (CBORCodec) Encode(w io.Writer, v any) error {
+	fs, err := fields(v)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	b = appendCBORHead(b, 5, uint64(len(fs)))
+	for _, f := range fs {
+		b = appendCBORValue(b, reflect.ValueOf(f.name))
+		b = appendCBORValue(b, f.value)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// appendCBORHead appends head of CBOR data item of major type with argument n to b.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+// appendCBORValue appends v, which must be encodable, to b.
+func appendCBORValue(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.String:
+		return append(appendCBORHead(b, 3, uint64(v.Len())), v.String()...)
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n < 0 {
+			return appendCBORHead(b, 1, uint64(-1-n))
+		}
+		return appendCBORHead(b, 0, uint64(v.Int()))
+	case reflect.Map:
+		b = appendCBORHead(b, 5, uint64(v.Len()))
+		for _, key := range sortedKeys(v) {
+			b = appendCBORValue(b, key)
+			b = appendCBORValue(b, v.MapIndex(key))
+		}
+		return b
+	default:
+		return appendCBORHead(b, 0, v.Uint())
+	}
+}
+
+// MessagePackCodec encodes payloads as MessagePack map keyed by field names.
+// Integers are encoded in their shortest form and map keys are sorted.
+type MessagePackCodec struct{}
+
+// MediaTypes implements Codec.
+func (MessagePackCodec) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+// ContentType implements Codec.
+func (MessagePackCodec) ContentType() string { return "application/msgpack" }
+
+// Encode implements Codec.
+func (MessagePackCodec) Encode(w io.Writer, v any) error {
+	fs, err := fields(v)
+	if err != nil {
+		return err
+	}
+
+	b := appendMessagePackMap(nil, len(fs))
+	for _, f := range fs {
+		b = appendMessagePackValue(b, reflect.ValueOf(f.name))
+		b = appendMessagePackValue(b, f.value)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// appendMessagePackMap appends header of map of n entries to b.
+func appendMessagePackMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// appendMessagePackUint appends unsigned integer n to b.
+func appendMessagePackUint(b []byte, n uint64) []byte {
+	switch {
+	case n < 128:
+		return append(b, byte(n))
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+	}
+}
+
+// appendMessagePackInt appends signed integer n to b.
+func appendMessagePackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendMessagePackUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// appendMessagePackValue appends v, which must be encodable, to b.
+func appendMessagePackValue(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.String:
+		switch n := v.Len(); {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+		}
+		return append(b, v.String()...)
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendMessagePackInt(b, v.Int())
+	case reflect.Map:
+		b = appendMessagePackMap(b, v.Len())
+		for _, key := range sortedKeys(v) {
+			b = appendMessagePackValue(b, key)
+			b = appendMessagePackValue(b, v.MapIndex(key))
+		}
+		return b
+	default:
+		return appendMessagePackUint(b, v.Uint())
+	}
+}
+
+// ProtobufCodec encodes payloads in protocol buffers wire format using field numbers given by proto tags,
+// messages are described by pkg/api/v1/api.proto. Signed integers are encoded as int64, maps as proto3 maps.
+// Fields holding zero values are omitted as proto3 does.
+type ProtobufCodec struct{}
+
+// MediaTypes implements Codec.
+func (ProtobufCodec) MediaTypes() []string {
+	return []string{"application/protobuf", "application/x-protobuf", "application/vnd.google.protobuf"}
+}
+
+// ContentType implements Codec.
+func (ProtobufCodec) ContentType() string { return "application/x-protobuf" }
+
+// Encode implements Codec.
+func (ProtobufCodec) Encode(w io.Writer, v any) error {
+	fs, err := fields(v)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	for _, f := range fs {
+		if f.num <= 0 {
+			return errors.Newf("field %s has no protobuf field number", f.name)
+		}
+		if f.value.IsZero() {
+			continue
+		}
+
+		if f.value.Kind() != reflect.Map {
+			b = appendProtobufValue(b, protowire.Number(f.num), f.value)
+			continue
+		}
+
+		// map entries are messages with key as field 1 and value as field 2
+		for _, key := range sortedKeys(f.value) {
+			var entry []byte
+			entry = appendProtobufValue(entry, 1, key)
+			entry = appendProtobufValue(entry, 2, f.value.MapIndex(key))
+
+			b = protowire.AppendTag(b, protowire.Number(f.num), protowire.BytesType)
+			b = protowire.AppendBytes(b, entry)
+		}
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// appendProtobufValue appends field num holding v, which must be a scalar, to b.
+func appendProtobufValue(b []byte, num protowire.Number, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.String:
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendString(b, v.String())
+	case reflect.Bool:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v.Int()))
+	default:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v.Uint())
+	}
+}
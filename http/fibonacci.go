@@ -16,12 +16,9 @@ type getFibonacciNumberFunc func(ctx context.Context) (int64, error)
 // GetCurrentFibonacciNumberFunc responds with the current number in the Fibonacci sequence.
 func GetCurrentFibonacciNumber(getCurrentFibonacciNumber getFibonacciNumberFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// It's always json.
-		w.Header().Set("Content-Type", "application/json")
-
 		number, err := getCurrentFibonacciNumber(r.Context())
 		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetCurrentFibonacciNumber",
 			}).Println("encountered an error retrieving Fibonacci number")
@@ -34,9 +31,8 @@ func GetCurrentFibonacciNumber(getCurrentFibonacciNumber getFibonacciNumberFunc)
 			Current: number,
 		}
 
-		w.WriteHeader(http.StatusOK)
-		if err := Marshal(w, &response); err != nil {
-			log.WithError(err).WithFields(log.Fields{
+		if err := Respond(w, r, http.StatusOK, &response); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetCurrentFibonacciNumber",
 			}).Println("unable to marshal response data")
@@ -49,23 +45,22 @@ func GetCurrentFibonacciNumber(getCurrentFibonacciNumber getFibonacciNumberFunc)
 // GetNextFibonacciNumberFunc responds with the next number in the Fibonacci sequence.
 func GetNextFibonacciNumberFunc(getNextFibonacciNumber getFibonacciNumberFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// It's always json.
-		w.Header().Set("Content-Type", "application/json")
-
 		number, err := getNextFibonacciNumber(r.Context())
 		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetNextFibonacciNumberFunc",
 			}).Println("encountered an error retrieving Fibonacci number")
 
-			w.WriteHeader(http.StatusInternalServerError)
-
 			if errors.Is(err, fibonacci.ErrCounterOverflow) {
-				Marshal(w, &api.Error{ 
-					Message: "counter overflow",
+				Respond(w, r, http.StatusInternalServerError, &api.Error{
+					Message:   "counter overflow",
+					RequestID: log.RequestIDFromContext(r.Context()),
 				})
+				return
 			}
+
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
@@ -73,14 +68,12 @@ func GetNextFibonacciNumberFunc(getNextFibonacciNumber getFibonacciNumberFunc) h
 			Next: number,
 		}
 
-		w.WriteHeader(http.StatusOK)
-		if err := Marshal(w, &response); err != nil {
-			log.WithError(err).WithFields(log.Fields{
+		if err := Respond(w, r, http.StatusOK, &response); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetNextFibonacciNumberFunc",
 			}).Println("unable to marshal response data")
 
-			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
@@ -89,23 +82,22 @@ func GetNextFibonacciNumberFunc(getNextFibonacciNumber getFibonacciNumberFunc) h
 // GetPreviousFibonacciNumberFunc responds with the next number in the Fibonacci sequence.
 func GetPreviousFibonacciNumberFunc(getPreviousFibonacciNumber getFibonacciNumberFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// It's always json.
-		w.Header().Set("Content-Type", "application/json")
-
 		number, err := getPreviousFibonacciNumber(r.Context())
 		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetPreviousFibonacciNumberFunc",
 			}).Println("encountered an error retrieving Fibonacci number")
 
-			w.WriteHeader(http.StatusInternalServerError)
-
 			if errors.Is(err, fibonacci.ErrCounterUnderflow) {
-				Marshal(w, &api.Error{
-					Message: "counter underflow",
+				Respond(w, r, http.StatusInternalServerError, &api.Error{
+					Message:   "counter underflow",
+					RequestID: log.RequestIDFromContext(r.Context()),
 				})
+				return
 			}
+
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
@@ -113,15 +105,42 @@ func GetPreviousFibonacciNumberFunc(getPreviousFibonacciNumber getFibonacciNumbe
 			Previous: number,
 		}
 
-		w.WriteHeader(http.StatusOK)
-		if err := Marshal(w, &response); err != nil {
-			log.WithError(err).WithFields(log.Fields{
+		if err := Respond(w, r, http.StatusOK, &response); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
 				"handler": "fibonacci",
 				"method":  "GetPreviousFibonacciNumberFunc",
 			}).Println("unable to marshal response data")
 
+			return
+		}
+	}
+}
+
+// ResetFibonacciNumberFunc moves the counter back to the lowest allowed term and responds with its number.
+func ResetFibonacciNumberFunc(resetFibonacciNumber getFibonacciNumberFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		number, err := resetFibonacciNumber(r.Context())
+		if err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "fibonacci",
+				"method":  "ResetFibonacciNumberFunc",
+			}).Println("encountered an error retrieving Fibonacci number")
+
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		response := api.CurrentFibonacciNumberResponse{
+			Current: number,
+		}
+
+		if err := Respond(w, r, http.StatusOK, &response); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "fibonacci",
+				"method":  "ResetFibonacciNumberFunc",
+			}).Println("unable to marshal response data")
+
+			return
+		}
 	}
 }
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+// healthCheckTimeout bounds time a single health check is allowed to run.
+const healthCheckTimeout = 2 * time.Second
+
+// errNotReady is reported by readiness probe when service is not accepting traffic.
+var errNotReady = errors.New("service is not ready")
+
+// Checker checks health of a single service component.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Health is a registry of health checkers backing liveness and readiness probes.
+//
+// Liveness checks report whether the service is healthy at all, for example whether counter invariants hold,
+// failing them means the service should be restarted. Readiness checks additionally report whether the service
+// is able to accept traffic, for example whether dependencies are reachable.
+// It is safe to use Health concurrently.
+type Health struct {
+	mu        sync.RWMutex
+	liveness  map[string]Checker
+	readiness map[string]Checker
+
+	ready atomic.Bool
+}
+
+// NewHealth constructs a new Health having no checkers registered and not ready.
+func NewHealth() *Health {
+	return &Health{
+		liveness:  make(map[string]Checker),
+		readiness: make(map[string]Checker),
+	}
+}
+
+// RegisterLiveness registers a checker under name reported by both liveness and readiness probes.
+func (h *Health) RegisterLiveness(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness[name] = c
+}
+
+// RegisterReadiness registers a checker under name reported by readiness probe only.
+func (h *Health) RegisterReadiness(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness[name] = c
+}
+
+// SetReady marks service as ready or not ready to accept traffic.
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Live runs liveness checks and returns their results by name.
+func (h *Health) Live(ctx context.Context) map[string]error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return runChecks(ctx, h.liveness)
+}
+
+// Ready runs liveness and readiness checks and returns their results by name.
+func (h *Health) Ready(ctx context.Context) map[string]error {
+	h.mu.RLock()
+	results := runChecks(ctx, h.liveness)
+	for name, err := range runChecks(ctx, h.readiness) {
+		results[name] = err
+	}
+	h.mu.RUnlock()
+
+	if !h.ready.Load() {
+		results["ready"] = errNotReady
+	} else {
+		results["ready"] = nil
+	}
+	return results
+}
+
+// runChecks runs checks sequentially, each one bounded by healthCheckTimeout.
+func runChecks(ctx context.Context, checks map[string]Checker) map[string]error {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make(map[string]error, len(checks))
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		results[name] = checks[name].Check(ctx)
+		cancel()
+	}
+	return results
+}
+
+// GetHealthz responds whether the service is alive.
+func GetHealthz(health *Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondHealth(w, r, "GetHealthz", health.Live(r.Context()))
+	}
+}
+
+// GetReadyz responds whether the service is ready to accept traffic.
+func GetReadyz(health *Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondHealth(w, r, "GetReadyz", health.Ready(r.Context()))
+	}
+}
+
+// respondHealth responds with results of health checks, any failing check makes the service unavailable.
+func respondHealth(w http.ResponseWriter, r *http.Request, method string, results map[string]error) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	response := api.HealthResponse{
+		Status: api.StatusOK,
+		Checks: make(map[string]string, len(results)),
+	}
+
+	for name, err := range results {
+		if err != nil {
+			response.Status = api.StatusUnavailable
+			response.Checks[name] = err.Error()
+			continue
+		}
+		response.Checks[name] = api.StatusOK
+	}
+
+	status := http.StatusOK
+	if response.Status != api.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	if err := Respond(w, r, status, &response); err != nil {
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "health",
+			"method":  method,
+		}).Println("unable to marshal response data")
+	}
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/store"
+)
+
+func TestHealth(t *testing.T) {
+	ok := CheckerFunc(func(ctx context.Context) error { return nil })
+	failing := CheckerFunc(func(ctx context.Context) error { return errors.New("store unreachable") })
+
+	var testcases = []struct {
+		liveness  Checker
+		readiness Checker
+		ready     bool
+
+		healthz   string
+		healthzSC int
+		readyz    string
+		readyzSC  int
+	}{
+		// healthy and ready
+		{
+			liveness:  ok,
+			readiness: ok,
+			ready:     true,
+			healthz:   `{"status":"ok","checks":{"counter":"ok"}}`,
+			healthzSC: http.StatusOK,
+			readyz:    `{"status":"ok","checks":{"counter":"ok","ready":"ok","store":"ok"}}`,
+			readyzSC:  http.StatusOK,
+		},
+		// draining
+		{
+			liveness:  ok,
+			readiness: ok,
+			ready:     false,
+			healthz:   `{"status":"ok","checks":{"counter":"ok"}}`,
+			healthzSC: http.StatusOK,
+			readyz:    `{"status":"unavailable","checks":{"counter":"ok","ready":"service is not ready","store":"ok"}}`,
+			readyzSC:  http.StatusServiceUnavailable,
+		},
+		// failing readiness check does not affect liveness
+		{
+			liveness:  ok,
+			readiness: failing,
+			ready:     true,
+			healthz:   `{"status":"ok","checks":{"counter":"ok"}}`,
+			healthzSC: http.StatusOK,
+			readyz:    `{"status":"unavailable","checks":{"counter":"ok","ready":"ok","store":"store unreachable"}}`,
+			readyzSC:  http.StatusServiceUnavailable,
+		},
+		// failing liveness check
+		{
+			liveness:  failing,
+			readiness: ok,
+			ready:     true,
+			healthz:   `{"status":"unavailable","checks":{"counter":"store unreachable"}}`,
+			healthzSC: http.StatusServiceUnavailable,
+			readyz:    `{"status":"unavailable","checks":{"counter":"store unreachable","ready":"ok","store":"ok"}}`,
+			readyzSC:  http.StatusServiceUnavailable,
+		},
+	}
+
+	for i, tt := range testcases {
+		health := NewHealth()
+		health.RegisterLiveness("counter", tt.liveness)
+		health.RegisterReadiness("store", tt.readiness)
+		health.SetReady(tt.ready)
+
+		for _, probe := range []struct {
+			handler    http.HandlerFunc
+			response   string
+			statusCode int
+		}{
+			{GetHealthz(health), tt.healthz, tt.healthzSC},
+			{GetReadyz(health), tt.readyz, tt.readyzSC},
+		} {
+			w := httptest.NewRecorder()
+			probe.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if statusCode := w.Result().StatusCode; statusCode != probe.statusCode {
+				t.Errorf("#%d HTTP status got %v, want %v", i, statusCode, probe.statusCode)
+			}
+
+			// we do apply TrimSpace to clean up response coming from HTTP protocol
+			if response := strings.TrimSpace(w.Body.String()); response != probe.response {
+				t.Errorf("#%d HTTP response got %v, want %s", i, response, probe.response)
+			}
+		}
+	}
+}
+
+func TestReadyzStore(t *testing.T) {
+	var testcases = []struct {
+		dir string
+
+		statusCode int
+	}{
+		{t.TempDir(), http.StatusOK},
+		{filepath.Join(t.TempDir(), "missing"), http.StatusServiceUnavailable},
+	}
+
+	for i, tt := range testcases {
+		health := NewHealth()
+		health.RegisterReadiness("store", store.NewFile(filepath.Join(tt.dir, "state.json")))
+		health.SetReady(true)
+
+		w := httptest.NewRecorder()
+		GetReadyz(health)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if statusCode := w.Result().StatusCode; statusCode != tt.statusCode {
+			t.Errorf("#%d HTTP status got %v, want %v", i, statusCode, tt.statusCode)
+		}
+	}
+}
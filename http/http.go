@@ -1,6 +1,8 @@
 package http
 
-import "net/http"
+import (
+	"net/http"
+)
 
 // RequestUnmarshaler is any type capable to unmarshal data from HTTP request to itself.
 type RequestUnmarshaler interface {
@@ -21,13 +23,3 @@ type ResponseUnmarshaler interface {
 func UnmarshalResponse(r *http.Response, m ResponseUnmarshaler) error {
 	return m.UnmarshalHTTPResponse(r)
 }
-
-// Marshaler is any type capable to marshal data to suitable HTTP request payload.
-type Marshaler interface {
-	MarshalHTTP(w http.ResponseWriter) error
-}
-
-// Marshal marshals v into w.
-func Marshal(w http.ResponseWriter, m Marshaler) error {
-	return m.MarshalHTTP(w)
-}
@@ -0,0 +1,417 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// defaultJWTScopeClaim is claim scopes are read from when not configured, as defined by RFC 8693.
+const defaultJWTScopeClaim = "scope"
+
+// JWT validation errors, all of them wrap ErrInvalidCredentials.
+var (
+	errMalformedToken   = errors.Wrap(ErrInvalidCredentials, "malformed token")
+	errUnsupportedAlg   = errors.Wrap(ErrInvalidCredentials, "unsupported signing algorithm")
+	errUnknownKey       = errors.Wrap(ErrInvalidCredentials, "unknown signing key")
+	errInvalidSignature = errors.Wrap(ErrInvalidCredentials, "invalid signature")
+	errInvalidIssuer    = errors.Wrap(ErrInvalidCredentials, "invalid issuer")
+	errInvalidAudience  = errors.Wrap(ErrInvalidCredentials, "invalid audience")
+	errTokenExpired     = errors.Wrap(ErrInvalidCredentials, "token expired")
+	errTokenNotYetValid = errors.Wrap(ErrInvalidCredentials, "token not yet valid")
+	errMissingSubject   = errors.Wrap(ErrInvalidCredentials, "missing subject")
+)
+
+// jwtAlgorithm describes how tokens signed using a JWS algorithm are verified.
+type jwtAlgorithm struct {
+	hash  crypto.Hash
+	kty   string         // type of key verifying the signature
+	curve elliptic.Curve // curve of ECDSA key verifying the signature
+	size  int            // size of ECDSA signature part in bytes
+}
+
+// suits reports whether key is able to verify signatures of a, ECDSA keys must be on the curve a is defined for.
+func (a jwtAlgorithm) suits(key crypto.PublicKey) bool {
+	if pub, ok := key.(*ecdsa.PublicKey); ok {
+		return pub.Curve == a.curve
+	}
+	return true
+}
+
+// jwtAlgorithms are supported JWS algorithms, symmetric ones and "none" are deliberately not supported.
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": {hash: crypto.SHA256, kty: "RSA"},
+	"RS384": {hash: crypto.SHA384, kty: "RSA"},
+	"RS512": {hash: crypto.SHA512, kty: "RSA"},
+	"ES256": {hash: crypto.SHA256, kty: "EC", curve: elliptic.P256(), size: 32},
+	"ES384": {hash: crypto.SHA384, kty: "EC", curve: elliptic.P384(), size: 48},
+	"ES512": {hash: crypto.SHA512, kty: "EC", curve: elliptic.P521(), size: 66},
+}
+
+// jwk is a single JSON Web Key as defined by RFC 7517, only public signing keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA public key parameters.
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC public key parameters.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwtKey is a parsed public key verifying token signatures.
+type jwtKey struct {
+	alg string // algorithm key is restricted to, any algorithm of key type when empty
+	kty string
+	key crypto.PublicKey
+}
+
+// jwtHeader is JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are registered claims of a token validated by JWT.
+type jwtClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *int64      `json:"exp"`
+	NotBefore *int64      `json:"nbf"`
+}
+
+// jwtAudience is audience claim which might be either a single string or an array of them.
+type jwtAudience []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// JWT authenticates clients by JSON Web Token sent as a bearer token in Authorization header.
+//
+// Token signature is verified using public keys loaded from a JWKS file, which is reloaded once it changes
+// on disk. Issuer, audience and expiry of the token are checked and scopes granted by the token claim
+// are mapped to Scope values of the same name, unknown scopes are ignored.
+// It is safe to use JWT concurrently.
+type JWT struct {
+	cfg   JWTConfig
+	now   func() time.Time // now returns current time, replaced in tests
+	watch *fileWatch
+	keys  atomic.Pointer[map[string][]jwtKey] // keys by key ID
+}
+
+// NewJWT constructs a new JWT validating tokens as configured by cfg.
+func NewJWT(cfg JWTConfig) (*JWT, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("JWT issuer and audience must be configured")
+	}
+	if cfg.ScopeClaim == "" {
+		cfg.ScopeClaim = defaultJWTScopeClaim
+	}
+
+	j := &JWT{
+		cfg:   cfg,
+		now:   time.Now,
+		watch: newFileWatch(cfg.JWKSFile, fileWatchInterval),
+	}
+	if err := j.Reload(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Reload reloads keys from the JWKS file, keys loaded previously are kept if it fails.
+func (j *JWT) Reload() error {
+	apply, err := j.stage()
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// stage implements stager.
+func (j *JWT) stage() (func(), error) {
+	data, err := os.ReadFile(j.cfg.JWKSFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading JWKS file %s", j.cfg.JWKSFile)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrapf(err, "parsing JWKS file %s", j.cfg.JWKSFile)
+	}
+
+	keys := make(map[string][]jwtKey, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.parse()
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing JWKS file %s key #%d %q", j.cfg.JWKSFile, i, k.Kid)
+		}
+		keys[k.Kid] = append(keys[k.Kid], key)
+	}
+
+	return func() { j.keys.Store(&keys) }, nil
+}
+
+// Authenticate implements Authenticator.
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrMissingCredentials
+	}
+
+	if j.watch.changed(j.now()) {
+		if err := j.Reload(); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "auth",
+				"method":  "Authenticate",
+			}).Error("unable to reload JWKS, keeping previous keys")
+		}
+	}
+
+	return j.validate(strings.TrimSpace(token))
+}
+
+// validate verifies signature and claims of token and returns principal it identifies.
+func (j *JWT) validate(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	alg, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return nil, errUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	h := alg.hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	digest := h.Sum(nil)
+
+	if err := j.verify(header, alg, digest, signature); err != nil {
+		return nil, err
+	}
+
+	// Claims are parsed only after signature is verified, so they can be trusted.
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := j.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeJWTPart(parts[1], &raw); err != nil {
+		return nil, err
+	}
+
+	return &Principal{
+		ID:     "jwt:" + claims.Issuer + "/" + claims.Subject,
+		Name:   claims.Subject,
+		Scopes: jwtScopes(raw[j.cfg.ScopeClaim]),
+	}, nil
+}
+
+// verify verifies signature of digest using any key of header key ID suitable for alg.
+func (j *JWT) verify(header jwtHeader, alg jwtAlgorithm, digest, signature []byte) error {
+	keys := (*j.keys.Load())[header.Kid]
+
+	found := false
+	for _, key := range keys {
+		if key.kty != alg.kty || (key.alg != "" && key.alg != header.Alg) || !alg.suits(key.key) {
+			continue
+		}
+		found = true
+
+		switch pub := key.key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(pub, alg.hash, digest, signature) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			if len(signature) != 2*alg.size {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:alg.size])
+			s := new(big.Int).SetBytes(signature[alg.size:])
+			if ecdsa.Verify(pub, digest, r, s) {
+				return nil
+			}
+		}
+	}
+
+	if !found {
+		return errUnknownKey
+	}
+	return errInvalidSignature
+}
+
+// validateClaims checks issuer, subject, audience, expiry and not before time of claims.
+// Subject identifies the client, so tokens lacking it are rejected.
+func (j *JWT) validateClaims(claims jwtClaims) error {
+	if claims.Issuer != j.cfg.Issuer {
+		return errInvalidIssuer
+	}
+	if claims.Subject == "" {
+		return errMissingSubject
+	}
+
+	audience := false
+	for _, aud := range claims.Audience {
+		if aud == j.cfg.Audience {
+			audience = true
+			break
+		}
+	}
+	if !audience {
+		return errInvalidAudience
+	}
+
+	now := j.now()
+	if claims.ExpiresAt == nil || !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(j.cfg.Leeway)) {
+		return errTokenExpired
+	}
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0).Add(-j.cfg.Leeway)) {
+		return errTokenNotYetValid
+	}
+
+	return nil
+}
+
+// jwtScopes maps scope claim to scopes, claim might be either a space delimited string or an array of strings.
+func jwtScopes(claim json.RawMessage) []Scope {
+	var values []string
+	var delimited string
+	if err := json.Unmarshal(claim, &delimited); err == nil {
+		values = strings.Fields(delimited)
+	} else {
+		json.Unmarshal(claim, &values)
+	}
+
+	var scopes []Scope
+	for _, v := range values {
+		if scope := Scope(v); scope.valid() {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// decodeJWTPart decodes base64url encoded JSON part of a token into v.
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errMalformedToken
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errMalformedToken
+	}
+	return nil
+}
+
+// parse parses public key k describes.
+func (k jwk) parse() (jwtKey, error) {
+	key := jwtKey{alg: k.Alg, kty: k.Kty}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return key, errors.Wrap(err, "decoding modulus")
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return key, errors.Wrap(err, "decoding exponent")
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return key, errors.New("invalid exponent")
+		}
+		key.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return key, errors.Newf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return key, errors.Wrap(err, "decoding x coordinate")
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return key, errors.Wrap(err, "decoding y coordinate")
+		}
+		if !curve.IsOnCurve(x, y) {
+			return key, errors.New("point is not on curve")
+		}
+		key.key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	default:
+		return key, errors.Newf("unsupported key type %q", k.Kty)
+	}
+
+	return key, nil
+}
+
+// decodeJWKInt decodes base64url encoded big-endian unsigned integer.
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
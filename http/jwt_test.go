@@ -0,0 +1,310 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// signJWT signs claims with key using alg and returns resulting token.
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+
+	h := jwtAlgorithms[alg].hash.New()
+	h.Write([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, jwtAlgorithms[alg].hash, h.Sum(nil)); err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writeJWKS writes JWKS file at path holding public keys by key ID.
+func writeJWKS(t *testing.T, path string, keys map[string]crypto.Signer) {
+	t.Helper()
+
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kty: "RSA", Kid: kid, Use: "sig", N: encode(pub.N), E: encode(big.NewInt(int64(pub.E)))})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kty: "EC", Kid: kid, Crv: pub.Curve.Params().Name, X: encode(pub.X), Y: encode(pub.Y)})
+		}
+	}
+
+	data, err := json.Marshal(&set)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.Signer{"rsa": rsaKey, "ec": ecKey, "p384": p384Key})
+
+	auth, err := NewJWT(JWTConfig{JWKSFile: path, Issuer: "gateway", Audience: "fibonacci", Leeway: time.Minute})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   "gateway",
+			"sub":   "client",
+			"aud":   "fibonacci",
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "read write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	var testcases = []struct {
+		header string
+
+		scopes []Scope
+		err    error
+	}{
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(nil)),
+			scopes: []Scope{ScopeRead, ScopeWrite},
+		},
+		{
+			header: "bearer " + signJWT(t, "ES256", "ec", ecKey, claims(map[string]interface{}{
+				"aud":   []string{"other", "fibonacci"},
+				"scope": "read root",
+			})),
+			scopes: []Scope{ScopeRead},
+		},
+		{
+			header: "Bearer " + signJWT(t, "ES384", "p384", p384Key, claims(nil)),
+			scopes: []Scope{ScopeRead, ScopeWrite},
+		},
+		{
+			header: "Bearer " + signJWT(t, "ES256", "p384", p384Key, claims(nil)),
+			err:    errUnknownKey,
+		},
+		{
+			header: "Bearer " + signJWT(t, "ES384", "ec", ecKey, claims(nil)),
+			err:    errUnknownKey,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})),
+			err:    errTokenExpired,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"exp": nil})),
+			err:    errTokenExpired,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+			err:    errTokenNotYetValid,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"iss": "attacker"})),
+			err:    errInvalidIssuer,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"aud": "other"})),
+			err:    errInvalidAudience,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"sub": nil})),
+			err:    errMissingSubject,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, claims(map[string]interface{}{"sub": ""})),
+			err:    errMissingSubject,
+		},
+		{
+			header: "Bearer " + signJWT(t, "ES256", "ec", otherKey, claims(nil)),
+			err:    errInvalidSignature,
+		},
+		{
+			header: "Bearer " + signJWT(t, "ES256", "unknown", ecKey, claims(nil)),
+			err:    errUnknownKey,
+		},
+		{
+			header: "Bearer " + signJWT(t, "RS256", "ec", ecKey, claims(nil)),
+			err:    errUnknownKey,
+		},
+		{
+			header: "Bearer eyJhbGciOiJub25lIn0.e30.",
+			err:    errUnsupportedAlg,
+		},
+		{
+			header: "Bearer not-a-token",
+			err:    errMalformedToken,
+		},
+		{
+			header: "Basic dXNlcjpwYXNz",
+			err:    ErrMissingCredentials,
+		},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.Header.Set("Authorization", tt.header)
+
+		principal, err := auth.Authenticate(r)
+		if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+			continue
+		}
+		if tt.err != ErrMissingCredentials && err != nil && !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("#%d got %v, want %v", i, err, ErrInvalidCredentials)
+		}
+		if err != nil {
+			continue
+		}
+
+		if principal.ID != "jwt:gateway/client" || principal.Name != "client" || len(principal.Scopes) != len(tt.scopes) {
+			t.Errorf("#%d got %v, want %v", i, principal, tt.scopes)
+			continue
+		}
+		for j := range tt.scopes {
+			if principal.Scopes[j] != tt.scopes[j] {
+				t.Errorf("#%d got %v, want %v", i, principal.Scopes, tt.scopes)
+			}
+		}
+	}
+}
+
+func TestJWTReload(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.Signer{"old": oldKey})
+
+	auth, err := NewJWT(JWTConfig{JWKSFile: path, Issuer: "gateway", Audience: "fibonacci"})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	authenticate := func(kid string, key crypto.Signer) error {
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.Header.Set("Authorization", "Bearer "+signJWT(t, "ES256", kid, key, map[string]interface{}{
+			"iss": "gateway",
+			"sub": "client",
+			"aud": "fibonacci",
+			"exp": now.Add(time.Hour).Unix(),
+		}))
+		_, err := auth.Authenticate(r)
+		return err
+	}
+
+	if err := authenticate("old", oldKey); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+
+	// keys are rotated on disk, modification time is moved forward as file systems might have coarse timestamps
+	writeJWKS(t, path, map[string]crypto.Signer{"new": newKey})
+	if err := os.Chtimes(path, now.Add(time.Minute), now.Add(time.Minute)); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	now = now.Add(fileWatchInterval)
+
+	if err := authenticate("new", newKey); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+	if err := authenticate("old", oldKey); !errors.Is(err, errUnknownKey) {
+		t.Errorf("got %v, want %v", err, errUnknownKey)
+	}
+
+	// broken file keeps previously loaded keys
+	if err := os.WriteFile(path, []byte(`{"keys": [`), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if err := os.Chtimes(path, now.Add(2*time.Minute), now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	now = now.Add(fileWatchInterval)
+
+	if err := authenticate("new", newKey); err != nil {
+		t.Errorf("got %v, want %v", err, nil)
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes all exported metric names.
+const metricsNamespace = "fibonacci"
+
+// Metrics collects service metrics and exposes them in Prometheus format.
+// It is safe to use Metrics concurrently.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	connections *prometheus.GaugeVec
+	errors      *prometheus.CounterVec
+}
+
+// NewMetrics constructs Metrics registering counter position of app and Go runtime collectors.
+func NewMetrics(app *fibonacci.Fibonacci) *Metrics {
+	m := Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latencies by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "upgraded_connections",
+			Help:      "Number of open connections upgraded from HTTP, such as WebSocket ones, by route.",
+		}, []string{"route"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "counter",
+			Name:      "errors_total",
+			Help:      "Total number of counter overflow and underflow errors.",
+		}, []string{"error"}),
+	}
+
+	// Pre-initialise error series so they are exported before first error occurs.
+	m.errors.WithLabelValues("overflow")
+	m.errors.WithLabelValues("underflow")
+
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.connections,
+		m.errors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "counter",
+			Name:      "position",
+			Help:      "Current counter position in the sequence.",
+		}, func() float64 {
+			return float64(app.Position())
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &m
+}
+
+// Handler returns an http.Handler serving collected metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records request count and latency labelled by route template the request matched.
+// Connections upgraded from HTTP are counted as requests switching protocols, they last as long as clients
+// want, so their duration is not a latency, instead they are tracked as open connections until handler returns.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		hijacked := false
+		w = httpsnoop.Wrap(w, httpsnoop.Hooks{
+			Hijack: func(hijack httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+				return func() (net.Conn, *bufio.ReadWriter, error) {
+					conn, rw, err := hijack()
+					if err == nil {
+						hijacked = true
+						m.connections.WithLabelValues(route).Inc()
+					}
+					return conn, rw, err
+				}
+			},
+		})
+
+		snoop := httpsnoop.CaptureMetrics(next, w, r)
+
+		if hijacked {
+			m.connections.WithLabelValues(route).Dec()
+			m.requests.WithLabelValues(route, r.Method, strconv.Itoa(http.StatusSwitchingProtocols)).Inc()
+			return
+		}
+
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(snoop.Code)).Inc()
+		m.latency.WithLabelValues(route, r.Method).Observe(snoop.Duration.Seconds())
+	})
+}
+
+// observe records counter overflow and underflow errors.
+func (m *Metrics) observe(err error) {
+	switch {
+	case errors.Is(err, fibonacci.ErrCounterOverflow):
+		m.errors.WithLabelValues("overflow").Inc()
+	case errors.Is(err, fibonacci.ErrCounterUnderflow):
+		m.errors.WithLabelValues("underflow").Inc()
+	}
+}
+
+// instrumentedStepper records errors returned by the underlying stepper into metrics.
+type instrumentedStepper struct {
+	stepper
+	metrics *Metrics
+}
+
+// NextFibonacciNumber implements stepper.
+func (s *instrumentedStepper) NextFibonacciNumber(ctx context.Context) (int64, error) {
+	number, err := s.stepper.NextFibonacciNumber(ctx)
+	s.metrics.observe(err)
+	return number, err
+}
+
+// PreviousFibonacciNumber implements stepper.
+func (s *instrumentedStepper) PreviousFibonacciNumber(ctx context.Context) (int64, error) {
+	number, err := s.stepper.PreviousFibonacciNumber(ctx)
+	s.metrics.observe(err)
+	return number, err
+}
+
+// SeekFibonacciNumber implements stepper.
+func (s *instrumentedStepper) SeekFibonacciNumber(ctx context.Context, n int) (int64, error) {
+	number, err := s.stepper.SeekFibonacciNumber(ctx, n)
+	s.metrics.observe(err)
+	return number, err
+}
+
+// instrumentedNumbers records errors returned by the underlying numberStepper into metrics.
+type instrumentedNumbers struct {
+	numberStepper
+	metrics *Metrics
+}
+
+// NextNumber implements numberStepper.
+func (s *instrumentedNumbers) NextNumber(ctx context.Context) (fibonacci.Number, error) {
+	number, err := s.numberStepper.NextNumber(ctx)
+	s.metrics.observe(err)
+	return number, err
+}
+
+// PreviousNumber implements numberStepper.
+func (s *instrumentedNumbers) PreviousNumber(ctx context.Context) (fibonacci.Number, error) {
+	number, err := s.numberStepper.PreviousNumber(ctx)
+	s.metrics.observe(err)
+	return number, err
+}
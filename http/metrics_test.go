@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMetrics(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	metrics := NewMetrics(&sequence)
+
+	api := API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, metrics, NewHealth(), nil)
+	for _, path := range []string{"/next", "/next", "/previous", "/previous", "/previous", "/missing"} {
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/next", nil))
+
+	// counter errors are recorded when admin moves the counter as well
+	admin := Admin(&sequence, metrics, NewHealth(), nil, nil, nil, log.Discard())
+	admin.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counter/seek", strings.NewReader(`{"term": 1000}`)))
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if statusCode := w.Result().StatusCode; statusCode != http.StatusOK {
+		t.Errorf("HTTP status got %v, want %v", statusCode, http.StatusOK)
+	}
+
+	for _, expected := range []string{
+		`fibonacci_http_requests_total{code="200",method="GET",route="/next"} 2`,
+		`fibonacci_http_requests_total{code="200",method="GET",route="/previous"} 2`,
+		`fibonacci_http_requests_total{code="500",method="GET",route="/previous"} 1`,
+		`fibonacci_http_requests_total{code="404",method="GET",route="unknown"} 1`,
+		`fibonacci_http_requests_total{code="405",method="POST",route="unknown"} 1`,
+		`fibonacci_http_request_duration_seconds_count{method="GET",route="/next"} 2`,
+		`fibonacci_counter_errors_total{error="overflow"} 1`,
+		`fibonacci_counter_errors_total{error="underflow"} 1`,
+		`fibonacci_counter_position 0`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("metrics got %s, want %s", w.Body.String(), expected)
+		}
+	}
+}
+
+func TestMetricsWebSocket(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	metrics := NewMetrics(&sequence)
+
+	server := httptest.NewServer(API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, metrics, NewHealth(), nil))
+	defer server.Close()
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/ws", nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	roundTrip(t, conn, api.StepCommand{Command: api.CommandCurrent})
+
+	if want := `fibonacci_http_upgraded_connections{route="/v1/ws"} 1`; !strings.Contains(scrape(), want) {
+		t.Errorf("metrics got %s, want %s", scrape(), want)
+	}
+
+	conn.Close()
+
+	// handler returns once it notices the connection is gone
+	want := `fibonacci_http_requests_total{code="101",method="GET",route="/v1/ws"} 1`
+	for deadline := time.Now().Add(time.Second); !strings.Contains(scrape(), want) && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	got := scrape()
+	for _, expected := range []string{want, `fibonacci_http_upgraded_connections{route="/v1/ws"} 0`} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("metrics got %s, want %s", got, expected)
+		}
+	}
+	if unexpected := `fibonacci_http_request_duration_seconds_count{method="GET",route="/v1/ws"}`; strings.Contains(got, unexpected) {
+		t.Errorf("metrics got %s, want no %s", got, unexpected)
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	apiv2 "github.com/deividaspetraitis/fibonacci/pkg/api/v2"
+)
+
+// numberStepper steps through the Fibonacci sequence responding with numbers along with their positions.
+type numberStepper interface {
+	CurrentNumber(ctx context.Context) (fibonacci.Number, error)
+	NextNumber(ctx context.Context) (fibonacci.Number, error)
+	PreviousNumber(ctx context.Context) (fibonacci.Number, error)
+}
+
+// getNumberFunc decouples actual Fibonacci number retrieval implementation and allows easily test HTTP handler.
+type getNumberFunc func(ctx context.Context) (fibonacci.Number, error)
+
+// GetNumber responds with number of the Fibonacci sequence retrieved by getNumber wrapped in apiv2.Number.
+// Counter overflow and underflow are rejected with 422 Unprocessable Entity.
+func GetNumber(getNumber getNumberFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		number, err := getNumber(r.Context())
+		if err != nil {
+			respondNumberError(w, r, err)
+			return
+		}
+
+		response := apiv2.Number{
+			Index:    number.Index,
+			Value:    strconv.FormatInt(number.Value, 10),
+			Sequence: apiv2.SequenceFibonacci,
+		}
+
+		if err := Respond(w, r, http.StatusOK, &response); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "number",
+				"method":  "GetNumber",
+			}).Println("unable to marshal response data")
+		}
+	}
+}
+
+// respondNumberError responds with err retrieving number failed with wrapped in apiv2.Error.
+func respondNumberError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := http.StatusInternalServerError, "internal error"
+	switch {
+	case errors.Is(err, fibonacci.ErrCounterOverflow):
+		status, message = http.StatusUnprocessableEntity, "counter overflow"
+	case errors.Is(err, fibonacci.ErrCounterUnderflow):
+		status, message = http.StatusUnprocessableEntity, "counter underflow"
+	default:
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "number",
+			"method":  "GetNumber",
+		}).Println("encountered an error retrieving Fibonacci number")
+	}
+
+	if err := Respond(w, r, status, &apiv2.Error{
+		Message:   message,
+		RequestID: log.RequestIDFromContext(r.Context()),
+	}); err != nil {
+		log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+			"handler": "number",
+			"method":  "GetNumber",
+		}).Println("unable to marshal response data")
+	}
+}
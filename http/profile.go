@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+// Captured profile kinds.
+const (
+	ProfileCPU   = "cpu"   // CPU profile sampled for a duration
+	ProfileHeap  = "heap"  // heap profile of live objects
+	ProfileTrace = "trace" // execution trace recorded for a duration
+)
+
+// Durations of profiles sampled over time.
+const (
+	DefaultProfileDuration = 30 * time.Second
+	MaxProfileDuration     = 5 * time.Minute
+)
+
+// profileTimeFormat is format of capture time in profile file names.
+const profileTimeFormat = "20060102T150405.000000000"
+
+// errProfileInProgress is returned when CPU profile or execution trace is requested while one is being captured.
+var errProfileInProgress = errors.New("profile capture is already in progress")
+
+// Profiles captures runtime profiles on demand writing them to a directory, so they can be collected
+// later with go tool pprof or go tool trace. Runtime allows a single CPU profile or execution trace to be
+// captured at once, others are rejected meanwhile.
+// It is safe to use Profiles concurrently.
+type Profiles struct {
+	dir string
+	now func() time.Time // now returns current time, replaced in tests
+
+	sampling sync.Mutex // held while CPU profile or execution trace is captured
+}
+
+// NewProfiles constructs a new Profiles writing profiles to dir, which is created if missing.
+func NewProfiles(dir string) (*Profiles, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.Wrapf(err, "creating profile directory %s", dir)
+	}
+	return &Profiles{dir: dir, now: time.Now}, nil
+}
+
+// Capture captures profile of kind, sampling it for d if it is sampled over time, and returns path of the file
+// it was written to. Sampling stops early once ctx is done, profile captured so far is still written.
+func (p *Profiles) Capture(ctx context.Context, kind string, d time.Duration) (string, error) {
+	switch kind {
+	case ProfileCPU:
+		return p.sample(ctx, kind, "pprof", d, pprof.StartCPUProfile, pprof.StopCPUProfile)
+	case ProfileTrace:
+		return p.sample(ctx, kind, "out", d, trace.Start, trace.Stop)
+	case ProfileHeap:
+		return p.write(kind, "pprof", func(f *os.File) error {
+			runtime.GC() // report objects live as of the last collection
+			return pprof.Lookup("heap").WriteTo(f, 0)
+		})
+	default:
+		return "", errors.Newf("profile %q is unknown", kind)
+	}
+}
+
+// sample records profile of kind for d using start and stop functions writing it to a file with ext.
+func (p *Profiles) sample(ctx context.Context, kind, ext string, d time.Duration, start func(w io.Writer) error, stop func()) (string, error) {
+	if !p.sampling.TryLock() {
+		return "", errProfileInProgress
+	}
+	defer p.sampling.Unlock()
+
+	return p.write(kind, ext, func(f *os.File) error {
+		// runtime refuses to start only when the profile is already being captured, by pprof handlers for example
+		if err := start(f); err != nil {
+			return errProfileInProgress
+		}
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+
+		stop()
+		return nil
+	})
+}
+
+// write creates a file named after kind and the current time with ext, writes profile to it and returns its path.
+// Incomplete file is removed when writing fails.
+func (p *Profiles) write(kind, ext string, profile func(f *os.File) error) (string, error) {
+	path := filepath.Join(p.dir, kind+"-"+p.now().UTC().Format(profileTimeFormat)+"."+ext)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating profile file %s", path)
+	}
+
+	err = profile(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", errors.Wrapf(err, "capturing %s profile", kind)
+	}
+
+	return path, nil
+}
+
+// CaptureProfile captures profile of kind responding with api.ProfileResponse naming the file it was written to.
+// Profiles sampled over time are sampled for duration given in seconds query parameter, DefaultProfileDuration
+// by default and at most MaxProfileDuration.
+func CaptureProfile(profiles *Profiles, kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d := DefaultProfileDuration
+		if seconds := r.URL.Query().Get("seconds"); seconds != "" {
+			n, err := strconv.Atoi(seconds)
+			if err != nil || n <= 0 || time.Duration(n)*time.Second > MaxProfileDuration {
+				respondError(w, r, http.StatusBadRequest, "invalid duration")
+				return
+			}
+			d = time.Duration(n) * time.Second
+		}
+
+		path, err := profiles.Capture(r.Context(), kind, d)
+		if err != nil {
+			if errors.Is(err, errProfileInProgress) {
+				respondError(w, r, http.StatusConflict, errProfileInProgress.Error())
+				return
+			}
+
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "admin",
+				"method":  "CaptureProfile",
+			}).Println("unable to capture profile")
+
+			respondError(w, r, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		log.WithContext(r.Context()).WithFields(log.Fields{"profile": kind, "file": path}).Info("profile captured")
+
+		if err := Respond(w, r, http.StatusOK, &api.ProfileResponse{Profile: kind, File: path}); err != nil {
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "admin",
+				"method":  "CaptureProfile",
+			}).Println("unable to marshal response data")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+)
+
+func TestProfilesCapture(t *testing.T) {
+	profiles, err := NewProfiles(filepath.Join(t.TempDir(), "profiles"))
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var testcases = []struct {
+		kind string
+
+		ext string
+		err bool
+	}{
+		{kind: ProfileCPU, ext: ".pprof"},
+		{kind: ProfileHeap, ext: ".pprof"},
+		{kind: ProfileTrace, ext: ".out"},
+		{kind: "goroutine", err: true},
+	}
+
+	for i, tt := range testcases {
+		path, err := profiles.Capture(context.TODO(), tt.kind, 10*time.Millisecond)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+		}
+		if tt.err {
+			continue
+		}
+
+		if filepath.Ext(path) != tt.ext {
+			t.Errorf("#%d got %v, want %v", i, filepath.Ext(path), tt.ext)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("#%d got %v, want non empty file", i, err)
+		}
+	}
+
+	// CPU profile and trace are not captured concurrently
+	profiles.sampling.Lock()
+	if _, err := profiles.Capture(context.TODO(), ProfileTrace, time.Millisecond); !errors.Is(err, errProfileInProgress) {
+		t.Errorf("got %v, want %v", err, errProfileInProgress)
+	}
+	profiles.sampling.Unlock()
+}
+
+func TestAdminAuth(t *testing.T) {
+	profiles, err := NewProfiles(t.TempDir())
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var sequence fibonacci.Fibonacci
+	auth := NewBearerToken("admin", "admin-token", ScopeAdmin)
+	handler := Admin(&sequence, NewMetrics(&sequence), NewHealth(), nil, profiles, auth, log.Discard())
+
+	var testcases = []struct {
+		method string
+		path   string
+		token  string
+
+		code int
+	}{
+		{method: http.MethodGet, path: "/metrics", code: http.StatusOK},
+		{method: http.MethodGet, path: "/healthz", code: http.StatusOK},
+		{method: http.MethodGet, path: "/counter", code: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/debug/pprof/", code: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/debug/pprof/", token: "wrong-token", code: http.StatusUnauthorized},
+		{method: http.MethodPost, path: "/debug/capture/heap", code: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/counter", token: "admin-token", code: http.StatusOK},
+		{method: http.MethodGet, path: "/debug/pprof/", token: "admin-token", code: http.StatusOK},
+		{method: http.MethodPost, path: "/debug/capture/cpu?seconds=0", token: "admin-token", code: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/debug/capture/cpu?seconds=301", token: "admin-token", code: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/debug/capture/heap", token: "admin-token", code: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.code {
+			t.Errorf("#%d %s code got %v, want %v", i, tt.path, w.Code, tt.code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/debug/capture/heap", nil)
+	r.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var body api.ProfileResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if _, err := os.Stat(body.File); body.Profile != ProfileHeap || err != nil {
+		t.Errorf("got %v, %v, want %v file", body, err, ProfileHeap)
+	}
+}
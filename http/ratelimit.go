@@ -0,0 +1,170 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// APIKeyHeader is HTTP header carrying client API key.
+const APIKeyHeader = "X-API-Key"
+
+// Rate limit response headers.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// rateLimitSweepInterval is interval between removals of idle client buckets.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter limits rate of requests a single client is allowed to make to a route using token buckets.
+//
+// Authenticated clients are identified by principal, others by their IP address. Credentials which were not
+// verified are never used to identify clients, as clients could get a fresh bucket by making them up, so
+// requests are limited before being authorized, see Authenticate. Each client gets a separate bucket per route
+// sized by the route budget, buckets of idle clients are removed periodically. Budgets might be changed at runtime by SetConfig.
+// It is safe to use RateLimiter concurrently.
+type RateLimiter struct {
+	now func() time.Time // now returns current time, replaced in tests
+
+	mu      sync.Mutex
+	cfg     RateLimitConfig
+	buckets map[bucketKey]*bucket
+	swept   time.Time
+}
+
+// bucketKey identifies bucket of a single client on a single route.
+type bucketKey struct {
+	route  string
+	client string
+}
+
+// bucket is a token bucket of a single client on a single route.
+type bucket struct {
+	limiter *rate.Limiter
+	seen    time.Time // last time client made a request
+}
+
+// NewRateLimiter constructs a new RateLimiter enforcing budgets configured in cfg.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		now:     time.Now,
+		cfg:     cfg,
+		buckets: make(map[bucketKey]*bucket),
+	}
+}
+
+// SetConfig replaces budgets enforced by l with ones configured in cfg.
+// Buckets of known clients are resized keeping tokens they hold, so changing budgets does not reset limits.
+func (l *RateLimiter) SetConfig(cfg RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg = cfg
+
+	now := l.now()
+	for key, b := range l.buckets {
+		budget := cfg.budget(key.route)
+		if budget.Rate <= 0 {
+			delete(l.buckets, key)
+			continue
+		}
+		b.limiter.SetLimitAt(now, rate.Limit(budget.Rate))
+		b.limiter.SetBurstAt(now, budget.Burst)
+	}
+}
+
+// Limit limits rate of requests made to next served as route.
+// Requests exceeding the route budget are rejected with 429 Too Many Requests telling when to retry.
+// Requests are let through as is while no budget is configured for route nor by default.
+func (l *RateLimiter) Limit(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := l.now()
+		limiter, budget := l.limiter(bucketKey{route: route, client: clientKey(r)}, now)
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reservation := limiter.ReserveN(now, 1)
+		delay := reservation.DelayFrom(now)
+		if delay > 0 {
+			// request is rejected, so it must not consume future tokens
+			reservation.CancelAt(now)
+		}
+
+		tokens := limiter.TokensAt(now)
+		w.Header().Set(RateLimitLimitHeader, strconv.Itoa(budget.Burst))
+		w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+		w.Header().Set(RateLimitResetHeader, strconv.Itoa(seconds(time.Duration((float64(budget.Burst)-tokens)/budget.Rate*float64(time.Second)))))
+
+		if delay > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(delay)))
+			respondError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limiter returns limiter of bucket identified by key creating it if needed along with the route budget.
+// Nil limiter is returned when no budget is configured for the route.
+func (l *RateLimiter) limiter(key bucketKey, now time.Time) (*rate.Limiter, RateLimitBudget) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	budget := l.cfg.budget(key.route)
+	if budget.Rate <= 0 {
+		return nil, budget
+	}
+
+	if now.Sub(l.swept) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(budget.Rate), budget.Burst)}
+		l.buckets[key] = b
+	}
+	b.seen = now
+
+	return b.limiter, budget
+}
+
+// sweep removes buckets idle long enough to be refilled completely, they are no different from new ones.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		budget := l.cfg.budget(key.route)
+		if now.Sub(b.seen).Seconds() >= float64(budget.Burst)/budget.Rate {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// clientKey identifies client making request r by authenticated principal or by IP address otherwise.
+func clientKey(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil && principal.ID != "" {
+		return "principal:" + principal.ID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds returns d rounded up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{
+		RateLimitBudget: RateLimitBudget{Rate: 100},
+		Routes: map[string]RateLimitBudget{
+			"next": {Rate: 1, Burst: 2},
+		},
+	})
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Limit("next", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var testcases = []struct {
+		remoteAddr string
+		apiKey     string // sent without authenticator verifying it
+		principal  string // ID of principal authenticated
+		elapsed    time.Duration
+
+		code       int
+		remaining  string
+		retryAfter string
+	}{
+		{remoteAddr: "10.0.0.1:1000", code: http.StatusOK, remaining: "1"},
+		{remoteAddr: "10.0.0.1:1001", apiKey: "first", code: http.StatusOK, remaining: "0"},
+		{remoteAddr: "10.0.0.1:1002", apiKey: "second", code: http.StatusTooManyRequests, remaining: "0", retryAfter: "1"},
+		{remoteAddr: "10.0.0.2:1000", code: http.StatusOK, remaining: "1"},
+		{remoteAddr: "10.0.0.1:1003", principal: "apikey:reader", code: http.StatusOK, remaining: "1"},
+		{remoteAddr: "10.0.0.1:1003", principal: "jwt:issuer/reader", code: http.StatusOK, remaining: "1"},
+		{remoteAddr: "10.0.0.1:1004", elapsed: time.Second, code: http.StatusOK, remaining: "0"},
+		{remoteAddr: "10.0.0.1:1005", code: http.StatusTooManyRequests, remaining: "0", retryAfter: "1"},
+	}
+
+	for i, tt := range testcases {
+		now = now.Add(tt.elapsed)
+
+		r := httptest.NewRequest(http.MethodGet, "/next", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.apiKey != "" {
+			r.Header.Set(APIKeyHeader, tt.apiKey)
+		}
+		if tt.principal != "" {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), &Principal{ID: tt.principal, Name: "reader"}))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.code {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, tt.code)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "2" {
+			t.Errorf("#%d limit got %v, want %v", i, got, "2")
+		}
+		if got := w.Header().Get(RateLimitRemainingHeader); got != tt.remaining {
+			t.Errorf("#%d remaining got %v, want %v", i, got, tt.remaining)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("#%d retry after got %v, want %v", i, got, tt.retryAfter)
+		}
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RateLimitBudget: RateLimitBudget{Rate: 1, Burst: 10}})
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Limit("current", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(remoteAddr string) {
+		r := httptest.NewRequest(http.MethodGet, "/current", nil)
+		r.RemoteAddr = remoteAddr
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	start := now
+	serve("10.0.0.1:1000")
+	now = start.Add(rateLimitSweepInterval - 5*time.Second)
+	serve("10.0.0.2:1000")
+
+	// first client bucket is refilled by now, second one is not yet
+	now = start.Add(rateLimitSweepInterval)
+	serve("10.0.0.3:1000")
+
+	if got := len(limiter.buckets); got != 2 {
+		t.Errorf("got %v, want %v", got, 2)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	limited := NewRateLimiter(RateLimitConfig{}).Limit("next", handler)
+
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/next", nil))
+
+	if got := w.Header().Get(RateLimitLimitHeader); got != "" {
+		t.Errorf("got %v, want %v", got, "")
+	}
+}
+
+func TestRateLimiterSetConfig(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{})
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Limit("next", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var testcases = []struct {
+		cfg *RateLimitConfig // replaces config before request when set
+
+		code  int
+		limit string
+	}{
+		{code: http.StatusOK},
+		{cfg: &RateLimitConfig{RateLimitBudget: RateLimitBudget{Rate: 1, Burst: 1}}, code: http.StatusOK, limit: "1"},
+		{code: http.StatusTooManyRequests, limit: "1"},
+		// bucket is resized keeping tokens, so the client gets only the new ones
+		{cfg: &RateLimitConfig{RateLimitBudget: RateLimitBudget{Rate: 1, Burst: 2}}, code: http.StatusTooManyRequests, limit: "2"},
+		{cfg: &RateLimitConfig{}, code: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		if tt.cfg != nil {
+			limiter.SetConfig(*tt.cfg)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/next", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.code {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, tt.code)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != tt.limit {
+			t.Errorf("#%d limit got %v, want %v", i, got, tt.limit)
+		}
+	}
+
+	if got := len(limiter.buckets); got != 0 {
+		t.Errorf("got %v, want %v", got, 0)
+	}
+}
+
+func TestRateLimiterUnauthenticated(t *testing.T) {
+	keys, err := NewAPIKeys(writeAPIKeys(t))
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var sequence fibonacci.Fibonacci
+	cfg := &Config{RateLimit: RateLimitConfig{RateLimitBudget: RateLimitBudget{Rate: 1, Burst: 2}}}
+	handler := API(NewApp(make(chan os.Signal, 1), log.Discard()), cfg, &sequence, NewMetrics(&sequence), NewHealth(), keys)
+
+	// guessing credentials exhausts budget of the client IP address, authenticated clients get their own one
+	var testcases = []struct {
+		key string
+
+		code int
+	}{
+		{key: "", code: http.StatusUnauthorized},
+		{key: "guess-1", code: http.StatusUnauthorized},
+		{key: "guess-2", code: http.StatusTooManyRequests},
+		{key: "reader-key", code: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/v1/current", nil)
+		if tt.key != "" {
+			r.Header.Set(APIKeyHeader, tt.key)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.code {
+			t.Errorf("#%d code got %v, want %v", i, w.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// RequestIDHeader is HTTP header carrying request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds length of request ID accepted from the client.
+const maxRequestIDLength = 128
+
+// RequestID accepts request ID sent by the client or generates a new one when it is missing or invalid,
+// stores it in the request context and echoes it in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(log.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether id is safe to be logged and echoed back.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a new random request ID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand never fails on supported platforms.
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequestID(t *testing.T) {
+	var testcases = []struct {
+		requestID string
+
+		echoed bool
+	}{
+		{
+			requestID: "3f2a-41b7_client.1:2",
+			echoed:    true,
+		},
+		{
+			requestID: "",
+		},
+		{
+			requestID: "bad\nid",
+		},
+		{
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+		},
+	}
+
+	for i, tt := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/next", nil)
+		req.Header.Set(RequestIDHeader, tt.requestID)
+		w := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.Use(RequestID)
+		router.HandleFunc("/next", GetNextFibonacciNumberFunc(func(ctx context.Context) (int64, error) {
+			return 0, fibonacci.ErrCounterOverflow
+		}))
+
+		router.ServeHTTP(w, req)
+
+		id := w.Result().Header.Get(RequestIDHeader)
+		if tt.echoed && id != tt.requestID {
+			t.Errorf("#%d request ID got %v, want %v", i, id, tt.requestID)
+		}
+		if !tt.echoed && (id == tt.requestID || len(id) != 32) {
+			t.Errorf("#%d request ID got %v, want generated one", i, id)
+		}
+
+		// we do apply TrimSpace to clean up response coming from HTTP protocol
+		expected := `{"error":"counter overflow","request_id":"` + id + `"}`
+		if response := strings.TrimSpace(w.Body.String()); response != expected {
+			t.Errorf("#%d HTTP response got %v, want %s", i, response, expected)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// TLS serves certificates and verifies client certificates loaded from files, which are reloaded
+// once they change on disk, so certificates can be rotated without restarting the server.
+// It is safe to use TLS concurrently.
+type TLS struct {
+	cfg    TLSConfig
+	logger log.Logger
+	now    func() time.Time // now returns current time, replaced in tests
+
+	watches []*fileWatch
+	config  atomic.Pointer[tls.Config]
+}
+
+// NewTLS constructs a new TLS loading certificate, its key and optional client CA configured by cfg.
+func NewTLS(cfg TLSConfig, logger log.Logger) (*TLS, error) {
+	if cfg.Cert == "" || cfg.Key == "" {
+		return nil, errors.New("both TLS certificate and key must be configured")
+	}
+
+	t := &TLS{
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+	for _, path := range []string{cfg.Cert, cfg.Key, cfg.ClientCA} {
+		if path != "" {
+			t.watches = append(t.watches, newFileWatch(path, fileWatchInterval))
+		}
+	}
+
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Config returns tls.Config to be used by http.Server, every handshake uses the latest loaded files.
+func (t *TLS) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: t.getConfigForClient,
+		// Not used during handshakes, but makes http.Server recognise certificates are provided.
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &t.config.Load().Certificates[0], nil
+		},
+	}
+}
+
+// Reload reloads certificate, its key and client CA, files loaded previously are kept if it fails.
+func (t *TLS) Reload() error {
+	cert, err := tls.LoadX509KeyPair(t.cfg.Cert, t.cfg.Key)
+	if err != nil {
+		return errors.Wrapf(err, "loading TLS certificate %s", t.cfg.Cert)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if t.cfg.ClientCA != "" {
+		data, err := os.ReadFile(t.cfg.ClientCA)
+		if err != nil {
+			return errors.Wrapf(err, "reading TLS client CA %s", t.cfg.ClientCA)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.Newf("TLS client CA %s holds no PEM encoded certificates", t.cfg.ClientCA)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	t.config.Store(config)
+	return nil
+}
+
+// getConfigForClient returns configuration of a handshake reloading files first if any of them changed.
+func (t *TLS) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	// every watch must be checked, so each of them records the latest state
+	now, changed := t.now(), false
+	for _, w := range t.watches {
+		if w.changed(now) {
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := t.Reload(); err != nil {
+			t.logger.WithError(err).WithFields(log.Fields{
+				"handler": "tls",
+				"method":  "getConfigForClient",
+			}).Error("unable to reload TLS files, keeping previous ones")
+		} else {
+			t.logger.Info("TLS files reloaded")
+		}
+	}
+
+	return t.config.Load(), nil
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+)
+
+// testCert is a locally generated certificate along with its key.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	tls  tls.Certificate
+}
+
+// newTestCert generates a certificate having serial number signed by parent, self-signed CA when parent is nil.
+func newTestCert(t *testing.T, serial int64, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "fibonacci"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	return &testCert{
+		cert: cert,
+		key:  key,
+		tls:  tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+// write writes PEM encoded certificate and its key to certPath and keyPath.
+func (c *testCert) write(t *testing.T, certPath, keyPath string) {
+	t.Helper()
+
+	key, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if keyPath == "" {
+		return
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+}
+
+// tlsGet makes a request to server over a new connection trusting ca and presenting client certificate if not nil.
+// It returns serial number of certificate server presented.
+func tlsGet(server *httptest.Server, ca *testCert, client *testCert) (int64, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	config := &tls.Config{RootCAs: roots}
+	if client != nil {
+		config.Certificates = []tls.Certificate{client.tls}
+	}
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: config, DisableKeepAlives: true}}
+	resp, err := c.Get(server.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.TLS.PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	cfg := TLSConfig{
+		Cert:     filepath.Join(dir, "server.crt"),
+		Key:      filepath.Join(dir, "server.key"),
+		ClientCA: filepath.Join(dir, "ca.crt"),
+	}
+
+	ca, otherCA := newTestCert(t, 1, nil), newTestCert(t, 2, nil)
+	ca.write(t, cfg.ClientCA, "")
+	newTestCert(t, 10, ca).write(t, cfg.Cert, cfg.Key)
+
+	certs, err := NewTLS(cfg, log.Discard())
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	now := time.Now()
+	certs.now = func() time.Time { return now }
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = certs.Config()
+	// rejected handshakes are expected
+	server.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	var testcases = []struct {
+		client *testCert
+		rotate bool // rotate server certificate before the request
+
+		serial int64
+		err    bool
+	}{
+		{client: nil, err: true},
+		{client: newTestCert(t, 20, otherCA), err: true},
+		{client: newTestCert(t, 21, ca), serial: 10},
+		{client: newTestCert(t, 22, ca), rotate: true, serial: 11},
+	}
+
+	for i, tt := range testcases {
+		if tt.rotate {
+			newTestCert(t, 11, ca).write(t, cfg.Cert, cfg.Key)
+			// file systems might have coarse timestamps
+			for _, path := range []string{cfg.Cert, cfg.Key} {
+				if err := os.Chtimes(path, now.Add(time.Minute), now.Add(time.Minute)); err != nil {
+					t.Fatalf("#%d got %v, want %v", i, err, nil)
+				}
+			}
+			now = now.Add(fileWatchInterval)
+		}
+
+		serial, err := tlsGet(server, ca, tt.client)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+			continue
+		}
+		if serial != tt.serial {
+			t.Errorf("#%d got %v, want %v", i, serial, tt.serial)
+		}
+	}
+}
+
+func TestTLSReloadFailure(t *testing.T) {
+	dir := t.TempDir()
+	cfg := TLSConfig{
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+	}
+
+	ca := newTestCert(t, 1, nil)
+	newTestCert(t, 10, ca).write(t, cfg.Cert, cfg.Key)
+
+	certs, err := NewTLS(cfg, log.Discard())
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	now := time.Now()
+	certs.now = func() time.Time { return now }
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = certs.Config()
+	server.StartTLS()
+	defer server.Close()
+
+	// certificate is replaced, but its key is not yet
+	newTestCert(t, 11, ca).write(t, cfg.Cert, "")
+	if err := os.Chtimes(cfg.Cert, now.Add(time.Minute), now.Add(time.Minute)); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	now = now.Add(fileWatchInterval)
+
+	serial, err := tlsGet(server, ca, nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if serial != 10 {
+		t.Errorf("got %v, want %v", serial, 10)
+	}
+}
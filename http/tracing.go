@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/deividaspetraitis/fibonacci/log"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records spans of inbound HTTP requests.
+var tracer = otel.Tracer("github.com/deividaspetraitis/fibonacci/http")
+
+// Tracing starts a span for each inbound request continuing trace context propagated in request headers.
+// Span is named after route template the request matched, unknown when it matched none.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		// request path would make span names unbounded
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPTarget(r.URL.RequestURI()),
+				attribute.String("http.request_id", log.RequestIDFromContext(r.Context())),
+			),
+		)
+		defer span.End()
+
+		snoop := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCode(snoop.Code))
+		if snoop.Code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(snoop.Code))
+		}
+	})
+}
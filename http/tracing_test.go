@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	var sequence fibonacci.Fibonacci
+	api := API(NewApp(make(chan os.Signal, 1), log.Discard()), &Config{}, &sequence, NewMetrics(&sequence), NewHealth(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/next", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	api.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+
+		if got, want := span.SpanContext().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+			t.Errorf("%s trace ID got %v, want %v", span.Name(), got, want)
+		}
+	}
+
+	// spans end in reverse order of their start
+	expected := []string{"Fibonacci.lock", "calcFiboncciTerm", "Fibonacci.NextFibonacciNumber", "GET /next"}
+	if len(names) != len(expected) {
+		t.Fatalf("got %v, want %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("got %v, want %v", names, expected)
+		}
+	}
+
+	if got, want := spans[len(spans)-1].Parent().SpanID().String(), "00f067aa0ba902b7"; got != want {
+		t.Errorf("parent span ID got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// fileWatchInterval is default interval watched files are checked for changes at.
+const fileWatchInterval = time.Second
+
+// fileWatch detects changes of a file by comparing its modification time and size at most once per interval.
+// Polling survives files being replaced by rename, as editors and secret managers tend to do.
+// It is safe to use fileWatch concurrently.
+type fileWatch struct {
+	path     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	modTime time.Time
+	size    int64
+}
+
+// newFileWatch constructs a new fileWatch of file at path, its current state is considered unchanged.
+func newFileWatch(path string, interval time.Duration) *fileWatch {
+	w := &fileWatch{path: path, interval: interval}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+	return w
+}
+
+// changed reports whether file changed since the last time it was reported so.
+// Missing file is reported unchanged, so the last loaded state is kept until it reappears.
+func (w *fileWatch) changed(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if now.Sub(w.checked) < w.interval {
+		return false
+	}
+	w.checked = now
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false
+	}
+
+	w.modTime, w.size = info.ModTime(), info.Size()
+	return true
+}
@@ -0,0 +1,350 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/errors"
+	"github.com/deividaspetraitis/fibonacci/log"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// wsWriteWait is time allowed to write a message to the peer.
+	wsWriteWait = 10 * time.Second
+
+	// wsMaxMessageSize is maximum command size allowed from the peer.
+	wsMaxMessageSize = 512
+
+	// wsSendBuffer is number of events buffered for a peer before it is considered too slow and dropped.
+	wsSendBuffer = 16
+
+	// wsMaxLimited is number of consecutive rate limited commands after which the peer is disconnected.
+	wsMaxLimited = 32
+)
+
+// WebSocket Errors
+var (
+	errUnknownCommand    = errors.New("unknown command")
+	errMalformedCommand  = errors.New("malformed command")
+	errRateLimitExceeded = errors.New("rate limit exceeded")
+	errForbidden         = errors.New("forbidden")
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// stepper is a Fibonacci sequence counter clients can step through over WebSocket connection.
+type stepper interface {
+	CurrentFibonacciNumber(ctx context.Context) (int64, error)
+	NextFibonacciNumber(ctx context.Context) (int64, error)
+	PreviousFibonacciNumber(ctx context.Context) (int64, error)
+	SeekFibonacciNumber(ctx context.Context, n int) (int64, error)
+}
+
+// Hub keeps track of WebSocket clients stepping through the same counter and
+// broadcasts counter updates made by one client to all the others.
+// It is safe to use Hub concurrently.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+	closed  bool
+
+	serving sync.WaitGroup // connections reading commands
+}
+
+// NewHub constructs a new empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*wsClient]struct{}),
+	}
+}
+
+// register adds c to the hub, it reports false when the hub is closed.
+// Registered client must be unregistered once it stops reading commands.
+func (h *Hub) register(c *wsClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	h.serving.Add(1)
+	return true
+}
+
+// unregister removes c from the hub and closes its send queue.
+func (h *Hub) unregister(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+	h.serving.Done()
+}
+
+// Close disconnects all clients and refuses new ones. It waits until commands being executed complete
+// or ctx is done, so the counter is not moved over WebSocket once Close returns nil.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	for c := range h.clients {
+		h.drop(c)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.serving.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drop removes c from the hub, h.mu must be held.
+func (h *Hub) drop(c *wsClient) {
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// send queues e to be sent to c.
+// Clients not keeping up with their send queue are dropped.
+func (h *Hub) send(c *wsClient, e api.StepEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.enqueue(c, e)
+}
+
+// broadcast queues e to be sent to all clients except from.
+func (h *Hub) broadcast(from *wsClient, e api.StepEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c != from {
+			h.enqueue(c, e)
+		}
+	}
+}
+
+// enqueue queues e into send queue of c, h.mu must be held.
+func (h *Hub) enqueue(c *wsClient, e api.StepEvent) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- e:
+	default:
+		h.drop(c)
+	}
+}
+
+// wsClient represents a single WebSocket connection registered in the Hub.
+type wsClient struct {
+	conn    *websocket.Conn
+	send    chan api.StepEvent
+	limiter *rate.Limiter
+}
+
+// readPump reads commands from the connection, executes them and queues results.
+// Counter updates are broadcast to other clients registered in the hub.
+func (c *wsClient) readPump(ctx context.Context, hub *Hub, seq stepper, pongWait time.Duration) {
+	defer hub.unregister(c)
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	limited := 0 // consecutive rate limited commands
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.WithContext(ctx).WithError(err).WithFields(log.Fields{
+					"handler": "websocket",
+					"method":  "readPump",
+				}).Println("unexpected connection close")
+			}
+			return
+		}
+
+		var cmd api.StepCommand
+
+		// Malformed commands are limited too, otherwise they could be flooded to get unlimited replies.
+		// Only the first command of a limited burst is replied to, so flooding does not fill the send queue,
+		// and peers keeping on flooding are disconnected.
+		if !c.limiter.Allow() {
+			limited++
+			if limited == 1 {
+				hub.send(c, stepError(ctx, cmd.Command, errRateLimitExceeded))
+			}
+			if limited >= wsMaxLimited {
+				c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, errRateLimitExceeded.Error()), time.Now().Add(wsWriteWait))
+				return
+			}
+			continue
+		}
+		limited = 0
+
+		if err := json.Unmarshal(message, &cmd); err != nil {
+			hub.send(c, stepError(ctx, cmd.Command, errMalformedCommand))
+			continue
+		}
+
+		if cmd.Command != api.CommandCurrent && !authorized(ctx, ScopeWrite) {
+			hub.send(c, stepError(ctx, cmd.Command, errForbidden))
+			continue
+		}
+
+		number, err := step(ctx, seq, cmd)
+		if err != nil {
+			hub.send(c, stepError(ctx, cmd.Command, err))
+			continue
+		}
+
+		hub.send(c, api.StepEvent{
+			Type:    api.EventResult,
+			Command: cmd.Command,
+			Value:   number,
+		})
+
+		if cmd.Command != api.CommandCurrent {
+			hub.broadcast(c, api.StepEvent{
+				Type:    api.EventUpdate,
+				Command: cmd.Command,
+				Value:   number,
+			})
+		}
+	}
+}
+
+// writePump writes queued events to the connection and keeps it alive by sending pings.
+func (c *wsClient) writePump(pingInterval time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case e, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				// The hub closed the queue.
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteJSON(&e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// step executes cmd against seq.
+func step(ctx context.Context, seq stepper, cmd api.StepCommand) (int64, error) {
+	switch cmd.Command {
+	case api.CommandCurrent:
+		return seq.CurrentFibonacciNumber(ctx)
+	case api.CommandNext:
+		return seq.NextFibonacciNumber(ctx)
+	case api.CommandPrevious:
+		return seq.PreviousFibonacciNumber(ctx)
+	case api.CommandSeek:
+		return seq.SeekFibonacciNumber(ctx, cmd.Term)
+	default:
+		return 0, errUnknownCommand
+	}
+}
+
+// authorized reports whether client the connection ctx belongs to is granted scope.
+// Connections not authenticated are authorized, as authentication is disabled then.
+func authorized(ctx context.Context, scope Scope) bool {
+	principal := PrincipalFromContext(ctx)
+	return principal == nil || principal.HasScope(scope)
+}
+
+// stepError constructs an event describing err returned by executing command.
+func stepError(ctx context.Context, command string, err error) api.StepEvent {
+	e := api.StepEvent{
+		Type:    api.EventResult,
+		Command: command,
+	}
+
+	switch {
+	case errors.Is(err, fibonacci.ErrCounterOverflow):
+		e.Error = "counter overflow"
+	case errors.Is(err, fibonacci.ErrCounterUnderflow):
+		e.Error = "counter underflow"
+	case errors.Is(err, errUnknownCommand), errors.Is(err, errMalformedCommand), errors.Is(err, errRateLimitExceeded),
+		errors.Is(err, errForbidden):
+		e.Error = err.Error()
+	default:
+		log.WithContext(ctx).WithError(err).WithFields(log.Fields{
+			"handler": "websocket",
+			"method":  "step",
+		}).Println("encountered an error retrieving Fibonacci number")
+
+		e.Error = "internal error"
+	}
+
+	return e
+}
+
+// FibonacciWebSocket upgrades connection to WebSocket and lets the client step through the Fibonacci sequence
+// by sending commands. Counter updates are broadcast to all other clients registered in hub.
+// Commands moving the counter require ScopeWrite granted to the principal connection was authenticated as.
+func FibonacciWebSocket(hub *Hub, seq stepper, cfg WebSocketConfig) http.HandlerFunc {
+	cfg = cfg.withDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Headers set by middleware, such as request ID or deprecation, are sent with the handshake response.
+		conn, err := upgrader.Upgrade(w, r, w.Header())
+		if err != nil {
+			// Upgrader has already replied with an HTTP error.
+			log.WithContext(r.Context()).WithError(err).WithFields(log.Fields{
+				"handler": "websocket",
+				"method":  "FibonacciWebSocket",
+			}).Println("unable to upgrade connection")
+
+			return
+		}
+
+		c := &wsClient{
+			conn:    conn,
+			send:    make(chan api.StepEvent, wsSendBuffer),
+			limiter: rate.NewLimiter(rate.Limit(cfg.RateLimit), cfg.Burst),
+		}
+		if !hub.register(c) {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(wsWriteWait))
+			conn.Close()
+			return
+		}
+
+		go c.writePump(cfg.PingInterval)
+		c.readPump(r.Context(), hub, seq, 2*cfg.PingInterval)
+	}
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci"
+	"github.com/deividaspetraitis/fibonacci/pkg/api/v1"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWebSocket connects to the WebSocket server running at server.
+func dialWebSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// roundTrip sends cmd over conn and returns the next event received.
+func roundTrip(t *testing.T, conn *websocket.Conn, cmd api.StepCommand) api.StepEvent {
+	t.Helper()
+
+	if err := conn.WriteJSON(&cmd); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	return readEvent(t, conn)
+}
+
+// readEvent reads the next event received over conn.
+func readEvent(t *testing.T, conn *websocket.Conn) api.StepEvent {
+	t.Helper()
+
+	var e api.StepEvent
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	return e
+}
+
+func TestFibonacciWebSocket(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	server := httptest.NewServer(FibonacciWebSocket(NewHub(), &sequence, WebSocketConfig{}))
+	defer server.Close()
+
+	a, b := dialWebSocket(t, server), dialWebSocket(t, server)
+
+	// make sure both clients are registered before stepping
+	roundTrip(t, a, api.StepCommand{Command: api.CommandCurrent})
+	roundTrip(t, b, api.StepCommand{Command: api.CommandCurrent})
+
+	var testcases = []struct {
+		command api.StepCommand
+
+		result    api.StepEvent
+		broadcast bool
+	}{
+		{
+			command:   api.StepCommand{Command: api.CommandNext},
+			result:    api.StepEvent{Type: api.EventResult, Command: api.CommandNext, Value: 1},
+			broadcast: true,
+		},
+		{
+			command:   api.StepCommand{Command: api.CommandSeek, Term: 10},
+			result:    api.StepEvent{Type: api.EventResult, Command: api.CommandSeek, Value: 55},
+			broadcast: true,
+		},
+		{
+			command:   api.StepCommand{Command: api.CommandPrevious},
+			result:    api.StepEvent{Type: api.EventResult, Command: api.CommandPrevious, Value: 34},
+			broadcast: true,
+		},
+		{
+			command: api.StepCommand{Command: api.CommandCurrent},
+			result:  api.StepEvent{Type: api.EventResult, Command: api.CommandCurrent, Value: 34},
+		},
+		{
+			command: api.StepCommand{Command: api.CommandSeek, Term: fibonacci.MaxThTerm + 1},
+			result:  api.StepEvent{Type: api.EventResult, Command: api.CommandSeek, Error: "counter overflow"},
+		},
+		{
+			command: api.StepCommand{Command: "reset"},
+			result:  api.StepEvent{Type: api.EventResult, Command: "reset", Error: "unknown command"},
+		},
+	}
+
+	for i, tt := range testcases {
+		if got := roundTrip(t, a, tt.command); got != tt.result {
+			t.Errorf("#%d result got %v, want %v", i, got, tt.result)
+		}
+
+		if !tt.broadcast {
+			continue
+		}
+
+		want := tt.result
+		want.Type = api.EventUpdate
+		if got := readEvent(t, b); got != want {
+			t.Errorf("#%d update got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestFibonacciWebSocketRateLimit(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	server := httptest.NewServer(FibonacciWebSocket(NewHub(), &sequence, WebSocketConfig{
+		RateLimit: 0.001,
+		Burst:     1,
+	}))
+	defer server.Close()
+
+	conn := dialWebSocket(t, server)
+
+	if got := roundTrip(t, conn, api.StepCommand{Command: api.CommandNext}); got.Error != "" {
+		t.Errorf("got %v, want %v", got.Error, "")
+	}
+
+	if got := roundTrip(t, conn, api.StepCommand{Command: api.CommandNext}); got.Error != "rate limit exceeded" {
+		t.Errorf("got %v, want %v", got.Error, "rate limit exceeded")
+	}
+
+	// the rest of the limited burst is not replied to, peer keeping on flooding is disconnected
+	for i := 1; i < wsMaxLimited; i++ {
+		if err := conn.WriteJSON(&api.StepCommand{Command: api.CommandNext}); err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("got %v, want close error", err)
+	}
+
+	// malformed commands consume the budget as well
+	conn = dialWebSocket(t, server)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("garbage")); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if got := readEvent(t, conn); got.Error != errMalformedCommand.Error() {
+		t.Errorf("got %v, want %v", got.Error, errMalformedCommand)
+	}
+
+	if got := roundTrip(t, conn, api.StepCommand{Command: api.CommandNext}); got.Error != "rate limit exceeded" {
+		t.Errorf("got %v, want %v", got.Error, "rate limit exceeded")
+	}
+}
+
+func TestFibonacciWebSocketScope(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	handler := FibonacciWebSocket(NewHub(), &sequence, WebSocketConfig{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := &Principal{Name: "reader", Scopes: []Scope{ScopeRead}}
+		handler.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+	}))
+	defer server.Close()
+
+	conn := dialWebSocket(t, server)
+
+	if got := roundTrip(t, conn, api.StepCommand{Command: api.CommandCurrent}); got.Error != "" {
+		t.Errorf("got %v, want %v", got.Error, "")
+	}
+
+	for _, command := range []string{api.CommandNext, api.CommandPrevious, api.CommandSeek} {
+		if got := roundTrip(t, conn, api.StepCommand{Command: command}); got.Error != "forbidden" {
+			t.Errorf("%s got %v, want %v", command, got.Error, "forbidden")
+		}
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	var sequence fibonacci.Fibonacci
+	hub := NewHub()
+	server := httptest.NewServer(FibonacciWebSocket(hub, &sequence, WebSocketConfig{}))
+	defer server.Close()
+
+	conn := dialWebSocket(t, server)
+	roundTrip(t, conn, api.StepCommand{Command: api.CommandNext})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := hub.Close(ctx); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// connected clients are disconnected
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
+		t.Errorf("got %v, want close error", err)
+	}
+
+	// new clients are refused
+	conn = dialWebSocket(t, server)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("got %v, want close error", err)
+	}
+
+	if got := sequence.Position(); got != 1 {
+		t.Errorf("got %v, want %v", got, 1)
+	}
+}
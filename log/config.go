@@ -0,0 +1,115 @@
+package log
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// Supported log outputs.
+const (
+	OutputStderr = "stderr"
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+)
+
+// Config represents logging configuration.
+type Config struct {
+	Level  string     `mapstructure:"level"`  // Lowest level of entries written, info by default.
+	Format string     `mapstructure:"format"` // Entries format: text, json or logfmt, text by default.
+	Output string     `mapstructure:"output"` // Entries destination: stderr, stdout or file, stderr by default.
+	File   FileConfig `mapstructure:"file"`   // Log file config, used when Output is file.
+}
+
+// FileConfig represents configuration of rotated log file.
+type FileConfig struct {
+	Path       string        `mapstructure:"path"`       // Path of the file being written.
+	MaxSize    int           `mapstructure:"maxsize"`    // Size in megabytes file is rotated at, 100 by default.
+	MaxAge     time.Duration `mapstructure:"maxage"`     // Age file is rotated at, never when zero.
+	MaxBackups int           `mapstructure:"maxbackups"` // Number of rotated files retained, all when zero.
+	Compress   bool          `mapstructure:"compress"`   // Whether rotated files are gzip compressed.
+}
+
+// Validate checks whether settings of c are sane and returns all problems found.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			errs = append(errs, errors.Newf("log level %q is unknown", c.Level))
+		}
+	}
+	if _, err := newFormatter(c.Format); err != nil {
+		errs = append(errs, errors.Newf("log format %q is unknown", c.Format))
+	}
+
+	switch c.Output {
+	case "", OutputStderr, OutputStdout:
+	case OutputFile:
+		if c.File.Path == "" {
+			errs = append(errs, errors.New("log file path must be set when output is file"))
+		}
+	default:
+		errs = append(errs, errors.Newf("log output %q is unknown", c.Output))
+	}
+
+	if c.File.MaxSize < 0 || c.File.MaxAge < 0 || c.File.MaxBackups < 0 {
+		errs = append(errs, errors.New("log file max size, age and backups must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// nopCloser is an io.Closer doing nothing.
+type nopCloser struct{}
+
+// Close implements io.Closer.
+func (nopCloser) Close() error { return nil }
+
+// Configure applies cfg to l returning closer of the output l writes to.
+// Nil cfg leaves l intact.
+func Configure(l Logger, cfg *Config) (io.Closer, error) {
+	if cfg == nil {
+		return nopCloser{}, nil
+	}
+
+	if cfg.Level != "" {
+		level, err := ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing log level")
+		}
+		l.SetLevel(level)
+	}
+
+	if err := l.SetFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+
+	// Standard streams are set as is, so text format is colored when writing to terminal, and are never closed.
+	var (
+		out    io.Writer
+		closer io.Closer = nopCloser{}
+	)
+	switch cfg.Output {
+	case "", OutputStderr:
+		out = os.Stderr
+	case OutputStdout:
+		out = os.Stdout
+	case OutputFile:
+		file, err := OpenRotatingFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = file, file
+	default:
+		return nil, errors.Newf("unknown log output %q", cfg.Output)
+	}
+
+	if err := l.SetOutput(out); err != nil {
+		closer.Close()
+		return nil, err
+	}
+	return closer, nil
+}
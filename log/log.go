@@ -1,12 +1,18 @@
 package log
 
 import (
+	"context"
+	"io"
 	"runtime"
+	"sync"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var defaultLogger *logrus.Entry = logrus.StandardLogger().WithField("go.version", runtime.Version())
+var defaultLogger = newEntry(logrus.StandardLogger())
 
 // Logger provides a leveled-logging interface.
 type Logger interface {
@@ -39,21 +45,156 @@ type Logger interface {
 	Warnf(format string, args ...interface{})
 	Warnln(args ...interface{})
 
-	WithError(err error) *logrus.Entry
+	WithError(err error) *Entry
+	WithFields(fields Fields) *Entry
+	WithContext(ctx context.Context) *Entry
 
-	// TODO:
 	// SetOutput sets output destination, it might be useful to suppress logging in tests.
-	// SetOutput(io.Writer) error
+	// Output is shared by the logger and all entries derived from it.
+	SetOutput(w io.Writer) error
+
+	// SetLevel sets the lowest level of entries written.
+	// Level is shared by the logger, all entries derived from it and its copies made by WithFormat.
+	SetLevel(level Level)
+
+	// SetFormat sets format entries are written in, one of FormatText, FormatJSON or FormatLogfmt.
+	// Format is shared by the logger and all entries derived from it.
+	SetFormat(format string) error
 }
 
 // Fields is used as argument in WithFields method/func
 type Fields = logrus.Fields
 
+// Level is a logging level.
+type Level = logrus.Level
+
+// Logging levels.
+const (
+	PanicLevel = logrus.PanicLevel
+	FatalLevel = logrus.FatalLevel
+	ErrorLevel = logrus.ErrorLevel
+	WarnLevel  = logrus.WarnLevel
+	InfoLevel  = logrus.InfoLevel
+	DebugLevel = logrus.DebugLevel
+	TraceLevel = logrus.TraceLevel
+)
+
+// ParseLevel takes a string level and returns the logging level constant.
+func ParseLevel(level string) (Level, error) {
+	return logrus.ParseLevel(level)
+}
+
 // Default is a default logger instance
 func Default() Logger {
 	return defaultLogger
 }
 
+// New constructs a new Logger writing text entries at InfoLevel and above to w.
+func New(w io.Writer) Logger {
+	logger := logrus.New()
+	logger.SetOutput(w)
+	return newEntry(logger)
+}
+
+// newEntry constructs a new Entry of logger carrying go version.
+func newEntry(logger *logrus.Logger) *Entry {
+	return &Entry{
+		Entry:  logger.WithField("go.version", runtime.Version()),
+		levels: &levels{loggers: []*logrus.Logger{logger}},
+	}
+}
+
+// levels is a group of loggers sharing their level.
+// It is safe to use levels concurrently.
+type levels struct {
+	mu      sync.Mutex
+	loggers []*logrus.Logger
+}
+
+// add adds logger to l setting its level to the level of the group.
+func (l *levels) add(logger *logrus.Logger) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logger.SetLevel(l.loggers[0].GetLevel())
+	l.loggers = append(l.loggers, logger)
+}
+
+// set sets level of every logger in l.
+func (l *levels) set(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, logger := range l.loggers {
+		logger.SetLevel(level)
+	}
+}
+
+// Discard constructs a new Logger discarding all entries.
+func Discard() Logger {
+	return New(io.Discard)
+}
+
+// loggerKey is context key of Logger.
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns Logger carried by ctx or the default one if there is none.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return defaultLogger
+}
+
+// Supported log entry formats.
+const (
+	FormatText   = "text"   // human friendly, colored when writing to terminal
+	FormatJSON   = "json"   // JSON object per line
+	FormatLogfmt = "logfmt" // key=value pairs per line, never colored
+)
+
+// newFormatter constructs a formatter of entries in format.
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return &logrus.TextFormatter{}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	case FormatLogfmt:
+		return &logrus.TextFormatter{DisableColors: true, FullTimestamp: true}, nil
+	default:
+		return nil, errors.Newf("unknown log format %q", format)
+	}
+}
+
+// WithFormat returns a copy of l writing entries formatted in format to the same output as l.
+// The copy shares level with l, so changing level of either changes both.
+// Unknown formats fall back to text, l is returned as is when it was not created by this package.
+func WithFormat(l Logger, format string) Logger {
+	entry, ok := l.(*Entry)
+	if !ok || entry.levels == nil {
+		return l
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(entry.Logger.Out)
+	logger.ReplaceHooks(entry.Logger.Hooks)
+	entry.levels.add(logger)
+
+	formatter, err := newFormatter(format)
+	if err != nil {
+		formatter = &logrus.TextFormatter{}
+	}
+	logger.SetFormatter(formatter)
+
+	return &Entry{Entry: logger.WithFields(entry.Data), levels: entry.levels}
+}
+
 func Print(args ...interface{})                 { defaultLogger.Print(args...) }
 func Printf(format string, args ...interface{}) { defaultLogger.Printf(format, args...) }
 func Println(args ...interface{})               { defaultLogger.Println(args...) }
@@ -86,28 +227,88 @@ func Warnln(args ...interface{})               { defaultLogger.Warnln(args...) }
 // the fields passed with WithField{,s}. It's finally logged when Trace, Debug,
 // Info, Warn, Error, Fatal or Panic is called on it. These objects can be
 // reused and passed around as much as you wish to avoid field duplication.
+// Entry implements Logger.
 type Entry struct {
 	*logrus.Entry
+	levels *levels // loggers sharing level with the one of the entry, nil when entry was not created by this package
 }
 
 // Add an error as single field (using the key defined in ErrorKey) to the Entry.
 func WithError(err error) *Entry {
-	var entry Entry
-
-	entry.Entry = defaultLogger.WithError(err)
-	return &entry
+	return defaultLogger.WithError(err)
 }
 
 // Add a map of fields to the Entry.
 func WithFields(fields Fields) *Entry {
-	var entry Entry
+	return defaultLogger.WithFields(fields)
+}
+
+// requestIDKey is context key of request ID.
+type requestIDKey struct{}
 
-	entry.Entry = defaultLogger.WithFields(logrus.Fields(fields))
-	return &entry
+// ContextWithRequestID returns a copy of ctx carrying request ID id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns request ID carried by ctx or empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Take Logger carried by ctx and add request ID and trace and span IDs of the span recorded in ctx to it,
+// so log entries can be correlated with requests and traces.
+func WithContext(ctx context.Context) *Entry {
+	return FromContext(ctx).WithContext(ctx)
+}
+
+// Add request ID and trace and span IDs of the span recorded in ctx to the Entry.
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	entry := e.Entry.WithContext(ctx)
+	if id := RequestIDFromContext(ctx); id != "" {
+		entry = entry.WithField("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry = entry.WithFields(logrus.Fields{
+			"trace_id": sc.TraceID().String(),
+			"span_id":  sc.SpanID().String(),
+		})
+	}
+	return &Entry{Entry: entry, levels: e.levels}
+}
+
+// Add an error as single field to the Entry.
+func (e *Entry) WithError(err error) *Entry {
+	return &Entry{Entry: e.Entry.WithError(err), levels: e.levels}
 }
 
 // Add a map of fields to the Entry.
 func (e *Entry) WithFields(fields Fields) *Entry {
-	e.Entry = e.Entry.WithFields(logrus.Fields(fields))
-	return e
+	return &Entry{Entry: e.Entry.WithFields(logrus.Fields(fields)), levels: e.levels}
+}
+
+// SetOutput implements Logger.
+func (e *Entry) SetOutput(w io.Writer) error {
+	e.Logger.SetOutput(w)
+	return nil
+}
+
+// SetLevel implements Logger.
+func (e *Entry) SetLevel(level Level) {
+	if e.levels == nil {
+		e.Logger.SetLevel(level)
+		return
+	}
+	e.levels.set(level)
+}
+
+// SetFormat implements Logger.
+func (e *Entry) SetFormat(format string) error {
+	formatter, err := newFormatter(format)
+	if err != nil {
+		return err
+	}
+	e.Logger.SetFormatter(formatter)
+	return nil
 }
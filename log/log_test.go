@@ -0,0 +1,118 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out)
+
+	ctx := NewContext(context.Background(), logger)
+	ctx = ContextWithRequestID(ctx, "abc")
+
+	WithContext(ctx).WithFields(Fields{"handler": "test"}).Info("captured")
+
+	for _, expected := range []string{`msg=captured`, `request_id=abc`, `handler=test`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("got %v, want %v", out.String(), expected)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default() {
+		t.Errorf("got %v, want %v", got, Default())
+	}
+
+	logger := Discard()
+	if got := FromContext(NewContext(context.Background(), logger)); got != logger {
+		t.Errorf("got %v, want %v", got, logger)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out)
+
+	logger.Debug("hidden")
+	if out.Len() != 0 {
+		t.Errorf("got %v, want %v", out.String(), "")
+	}
+
+	logger.SetLevel(DebugLevel)
+	logger.WithFields(Fields{"derived": true}).Debug("shown")
+	if !strings.Contains(out.String(), "shown") {
+		t.Errorf("got %v, want %v", out.String(), "shown")
+	}
+}
+
+func TestWithFieldsDoesNotMutate(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out)
+
+	logger.WithFields(Fields{"handler": "test"})
+	logger.Info("base")
+
+	if strings.Contains(out.String(), "handler") {
+		t.Errorf("got %v, want no handler field", out.String())
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	var testcases = []struct {
+		format string
+
+		expected string
+		err      bool
+	}{
+		{format: FormatJSON, expected: `"msg":"formatted"`},
+		{format: FormatLogfmt, expected: `msg=formatted`},
+		{format: "xml", err: true},
+	}
+
+	for i, tt := range testcases {
+		var out bytes.Buffer
+		logger := New(&out)
+
+		err := logger.SetFormat(tt.format)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+		}
+		if tt.err {
+			continue
+		}
+
+		logger.Info("formatted")
+		if !strings.Contains(out.String(), tt.expected) {
+			t.Errorf("#%d got %v, want %v", i, out.String(), tt.expected)
+		}
+	}
+}
+
+func TestWithFormatSharesLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out)
+	formatted := WithFormat(logger.WithFields(Fields{"handler": "test"}), FormatJSON)
+
+	formatted.Debug("hidden")
+	if out.Len() != 0 {
+		t.Errorf("got %v, want %v", out.String(), "")
+	}
+
+	logger.SetLevel(DebugLevel)
+	formatted.Debug("shown")
+	if !strings.Contains(out.String(), `"msg":"shown"`) || !strings.Contains(out.String(), `"handler":"test"`) {
+		t.Errorf("got %v, want %v", out.String(), `"msg":"shown"`)
+	}
+
+	out.Reset()
+	formatted.SetLevel(InfoLevel)
+	logger.Debug("hidden")
+	if out.Len() != 0 {
+		t.Errorf("got %v, want %v", out.String(), "")
+	}
+}
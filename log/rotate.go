@@ -0,0 +1,262 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// DefaultFileMaxSize is size in megabytes log file is rotated at when not configured.
+const DefaultFileMaxSize = 100
+
+// backupTimeFormat is format of time rotated log files are suffixed with, it sorts lexically.
+const backupTimeFormat = "20060102T150405.000"
+
+// compressSuffix is suffix of compressed rotated log files.
+const compressSuffix = ".gz"
+
+// RotatingFile is an io.WriteCloser writing to a file which is rotated once it grows larger than MaxSize
+// or gets older than MaxAge.
+//
+// Rotated file is renamed by suffixing its name with rotation time, for example serverd-20060102T150405.000.log,
+// optionally compressed and oldest rotated files above MaxBackups are removed. Compression and removal happen
+// in background and do not block writers.
+// It is safe to use RotatingFile concurrently.
+type RotatingFile struct {
+	cfg FileConfig
+	now func() time.Time // now returns current time, replaced in tests
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	mill sync.Mutex // serializes background compression and removal of rotated files
+	wg   sync.WaitGroup
+}
+
+// OpenRotatingFile opens file at cfg.Path for appending creating it if needed.
+func OpenRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("log file path is not set")
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultFileMaxSize
+	}
+
+	r := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write implements io.Writer rotating file beforehand if writing p would exceed limits.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.exceeded(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates file regardless of its size and age.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	return r.rotate()
+}
+
+// Close closes file waiting for background compression and removal of rotated files to finish.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// exceeded reports whether writing n more bytes would exceed size or age limits.
+// Empty file is never considered exceeding size limit, so a single large write still succeeds.
+func (r *RotatingFile) exceeded(n int64) bool {
+	if r.size > 0 && r.size+n > int64(r.cfg.MaxSize)*1024*1024 {
+		return true
+	}
+	return r.cfg.MaxAge > 0 && r.now().Sub(r.opened) >= r.cfg.MaxAge
+}
+
+// open opens file at configured path for appending.
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0o755); err != nil {
+		return errors.Wrapf(err, "creating log directory")
+	}
+
+	file, err := os.OpenFile(r.cfg.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "opening log file %s", r.cfg.Path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "reading log file %s info", r.cfg.Path)
+	}
+
+	r.file, r.size, r.opened = file, info.Size(), r.now()
+	return nil
+}
+
+// rotate closes current file, renames it and opens a new one in its place.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return errors.Wrapf(err, "closing log file %s", r.cfg.Path)
+	}
+
+	backup := r.backupName(r.now())
+	if err := os.Rename(r.cfg.Path, backup); err != nil {
+		// keep writing to the same file rather than losing entries
+		if err := r.open(); err != nil {
+			return err
+		}
+		return errors.Wrapf(err, "renaming log file %s", r.cfg.Path)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.millBackups(backup)
+	}()
+	return nil
+}
+
+// backupName returns name of file rotated at t.
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.cfg.Path)
+	return strings.TrimSuffix(r.cfg.Path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// millBackups compresses just rotated backup if configured and removes oldest rotated files above MaxBackups.
+// Failures are reported to stderr as the log file itself is what failed.
+func (r *RotatingFile) millBackups(backup string) {
+	r.mill.Lock()
+	defer r.mill.Unlock()
+
+	if r.cfg.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "log: compressing rotated log file: %v\n", err)
+		}
+	}
+
+	if r.cfg.MaxBackups <= 0 {
+		return
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: listing rotated log files: %v\n", err)
+		return
+	}
+
+	for len(backups) > r.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "log: removing rotated log file: %v\n", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// backups returns rotated files, both compressed and not, oldest first.
+func (r *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(r.cfg.Path)
+	prefix := filepath.Base(strings.TrimSuffix(r.cfg.Path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(r.cfg.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(r.cfg.Path), name))
+	}
+
+	// backup time format sorts lexically, compressed file sorts next to its uncompressed counterpart
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// compressFile gzip compresses file at path to path.gz removing the original.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listDir returns names of files in dir.
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "serverd.log")
+
+	file, err := OpenRotatingFile(FileConfig{Path: path, MaxSize: 1, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// each rotation must get distinct backup name
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	file.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	chunk := []byte(strings.Repeat("x", 512*1024-1) + "\n")
+	for i := 0; i < 8; i++ { // 2 chunks per file, 4 files in total
+		if _, err := file.Write(chunk); err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	names := listDir(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("got %v, want %v", names, "serverd.log and 2 compressed backups")
+	}
+
+	for _, name := range names[:2] {
+		if !strings.HasPrefix(name, "serverd-") || !strings.HasSuffix(name, ".log.gz") {
+			t.Errorf("got %v, want %v", name, "serverd-<time>.log.gz")
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		content, err := io.ReadAll(gz)
+		f.Close()
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		if len(content) != 2*len(chunk) {
+			t.Errorf("got %v, want %v", len(content), 2*len(chunk))
+		}
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "serverd.log")
+
+	file, err := OpenRotatingFile(FileConfig{Path: path, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	defer file.Close()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	file.now = func() time.Time { return now }
+	file.opened = now
+
+	var testcases = []struct {
+		elapsed time.Duration
+
+		files int
+	}{
+		{elapsed: 0, files: 1},
+		{elapsed: 30 * time.Minute, files: 1},
+		{elapsed: time.Hour, files: 2},
+		{elapsed: 90 * time.Minute, files: 2},
+		{elapsed: 2 * time.Hour, files: 3},
+	}
+
+	start := now
+	for i, tt := range testcases {
+		now = start.Add(tt.elapsed)
+		if _, err := file.Write([]byte("entry\n")); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+
+		if got := len(listDir(t, dir)); got != tt.files {
+			t.Errorf("#%d got %v, want %v", i, got, tt.files)
+		}
+	}
+}
+
+func TestConfigureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "serverd.log")
+
+	logger := Discard()
+	closer, err := Configure(logger, &Config{
+		Level:  "debug",
+		Format: FormatJSON,
+		Output: OutputFile,
+		File:   FileConfig{Path: path},
+	})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	logger.Debug("persisted")
+	if err := closer.Close(); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if !strings.Contains(string(content), `"msg":"persisted"`) {
+		t.Errorf("got %v, want %v", string(content), `"msg":"persisted"`)
+	}
+}
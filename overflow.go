@@ -0,0 +1,82 @@
+package fibonacci
+
+import (
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// OverflowPolicy defines how the counter behaves when stepping past its bounds.
+type OverflowPolicy string
+
+// Supported overflow policies.
+const (
+	OverflowError    OverflowPolicy = "error"    // step is rejected with ErrCounterOverflow or ErrCounterUnderflow
+	OverflowWrap     OverflowPolicy = "wrap"     // counter wraps around to the opposite bound
+	OverflowSaturate OverflowPolicy = "saturate" // counter stays at the bound
+	OverflowBounce   OverflowPolicy = "bounce"   // counter steps back in the other direction
+)
+
+// Config represents counter configuration.
+type Config struct {
+	Min      int            `mapstructure:"min"`      // lowest term counter is allowed to point to
+	Max      int            `mapstructure:"max"`      // highest term counter is allowed to point to, at most MaxThTerm
+	Overflow OverflowPolicy `mapstructure:"overflow"` // behaviour when stepping past the bounds
+}
+
+// DefaultConfig returns configuration of a zero value Fibonacci: the whole sequence fitting into int64
+// is walked and stepping past it is rejected.
+func DefaultConfig() Config {
+	return Config{Min: 0, Max: MaxThTerm, Overflow: OverflowError}
+}
+
+// Validate checks whether settings of c are sane and returns all problems found.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Min < 0 || c.Max > MaxThTerm || c.Min > c.Max {
+		errs = append(errs, errors.Newf("sequence bounds [%d, %d] must be within [0, %d]", c.Min, c.Max, MaxThTerm))
+	}
+
+	switch c.Overflow {
+	case "", OverflowError, OverflowWrap, OverflowSaturate, OverflowBounce:
+	default:
+		errs = append(errs, errors.Newf("sequence overflow policy %q is unknown", c.Overflow))
+	}
+
+	return errors.Join(errs...)
+}
+
+// step returns term counter pointing to n moves to by delta, which is either 1 or -1, applying policy of c.
+// ErrCounterOverflow or ErrCounterUnderflow is returned when the policy rejects the step.
+func (c *Config) step(n, delta int) (int, error) {
+	next := n + delta
+	if next >= c.Min && next <= c.Max {
+		return next, nil
+	}
+
+	switch c.Overflow {
+	case OverflowWrap:
+		if delta > 0 {
+			return c.Min, nil
+		}
+		return c.Max, nil
+
+	case OverflowSaturate:
+		if delta > 0 {
+			return c.Max, nil
+		}
+		return c.Min, nil
+
+	case OverflowBounce:
+		// nowhere to bounce when bounds allow a single term only
+		if back := n - delta; back >= c.Min && back <= c.Max {
+			return back, nil
+		}
+		return n, nil
+
+	default:
+		if delta > 0 {
+			return n, ErrCounterOverflow
+		}
+		return n, ErrCounterUnderflow
+	}
+}
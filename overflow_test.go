@@ -0,0 +1,106 @@
+package fibonacci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	var testcases = []struct {
+		policy   OverflowPolicy
+		min, max int
+		counter  int
+		delta    int
+
+		position int
+		expected int64
+		err      error
+	}{
+		{policy: OverflowError, max: MaxThTerm, counter: MaxThTerm, delta: 1, position: MaxThTerm, err: ErrCounterOverflow},
+		{policy: OverflowError, min: 5, max: 10, counter: 5, delta: -1, position: 5, err: ErrCounterUnderflow},
+		{policy: OverflowError, min: 5, max: 10, counter: 7, delta: 1, position: 8, expected: 21},
+		{policy: OverflowWrap, max: 10, counter: 10, delta: 1, position: 0, expected: 0},
+		{policy: OverflowWrap, min: 3, max: 10, counter: 3, delta: -1, position: 10, expected: 55},
+		{policy: OverflowSaturate, max: 10, counter: 10, delta: 1, position: 10, expected: 55},
+		{policy: OverflowSaturate, min: 3, max: 10, counter: 3, delta: -1, position: 3, expected: 2},
+		{policy: OverflowBounce, max: 10, counter: 10, delta: 1, position: 9, expected: 34},
+		{policy: OverflowBounce, min: 3, max: 10, counter: 3, delta: -1, position: 4, expected: 3},
+		{policy: OverflowBounce, min: 4, max: 4, counter: 4, delta: 1, position: 4, expected: 3},
+	}
+
+	for i, tt := range testcases {
+		var sequence Fibonacci
+		if err := sequence.SetConfig(Config{Min: tt.min, Max: tt.max, Overflow: tt.policy}); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		sequence.counter = tt.counter
+
+		walk := sequence.NextFibonacciNumber
+		if tt.delta < 0 {
+			walk = sequence.PreviousFibonacciNumber
+		}
+
+		got, err := walk(context.TODO())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d %s got %v, want %v", i, tt.policy, err, tt.err)
+		}
+		if got != tt.expected {
+			t.Errorf("#%d %s got %v, want %v", i, tt.policy, got, tt.expected)
+		}
+		if sequence.counter != tt.position {
+			t.Errorf("#%d %s counter got %v, want %v", i, tt.policy, sequence.counter, tt.position)
+		}
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	var testcases = []struct {
+		cfg     Config
+		counter int
+
+		position int
+		err      bool
+	}{
+		{cfg: Config{Min: 10, Max: 20}, counter: 5, position: 10},
+		{cfg: Config{Min: 10, Max: 20}, counter: 50, position: 20},
+		{cfg: Config{Min: 10, Max: 20, Overflow: OverflowWrap}, counter: 15, position: 15},
+		{cfg: Config{Min: -1, Max: 20}, counter: 15, position: 15, err: true},
+		{cfg: Config{Min: 0, Max: MaxThTerm + 1}, counter: 15, position: 15, err: true},
+		{cfg: Config{Min: 20, Max: 10}, counter: 15, position: 15, err: true},
+		{cfg: Config{Max: 20, Overflow: "explode"}, counter: 15, position: 15, err: true},
+	}
+
+	for i, tt := range testcases {
+		sequence := Fibonacci{counter: tt.counter}
+
+		err := sequence.SetConfig(tt.cfg)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d got %v, want error %v", i, err, tt.err)
+		}
+		if sequence.counter != tt.position {
+			t.Errorf("#%d counter got %v, want %v", i, sequence.counter, tt.position)
+		}
+		if err := sequence.Verify(context.TODO()); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		}
+	}
+}
+
+func TestSeekBounds(t *testing.T) {
+	var sequence Fibonacci
+	if err := sequence.SetConfig(Config{Min: 5, Max: 10, Overflow: OverflowWrap}); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	if _, err := sequence.SeekFibonacciNumber(context.TODO(), 11); !errors.Is(err, ErrCounterOverflow) {
+		t.Errorf("got %v, want %v", err, ErrCounterOverflow)
+	}
+	if _, err := sequence.SeekFibonacciNumber(context.TODO(), 4); !errors.Is(err, ErrCounterUnderflow) {
+		t.Errorf("got %v, want %v", err, ErrCounterUnderflow)
+	}
+	if got := sequence.Position(); got != 5 {
+		t.Errorf("got %v, want %v", got, 5)
+	}
+}
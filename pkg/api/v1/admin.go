@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SeekRequest represents a request to move the counter to a term.
+type SeekRequest struct {
+	Term int `json:"term"`
+}
+
+// UnmarshalHTTPRequest implements http.RequestUnmarshaler.
+func (r *SeekRequest) UnmarshalHTTPRequest(req *http.Request) error {
+	return json.NewDecoder(req.Body).Decode(r)
+}
+
+// CounterSnapshot represents a response describing state of the counter.
+type CounterSnapshot struct {
+	Position int    `json:"position" proto:"1"` // term counter points to
+	Current  int64  `json:"current" proto:"2"`  // number counter points to
+	Min      int    `json:"min" proto:"3"`      // lowest term counter is allowed to point to
+	Max      int    `json:"max" proto:"4"`      // highest term counter is allowed to point to
+	Overflow string `json:"overflow" proto:"5"` // behaviour when stepping past the bounds
+}
+
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *CounterSnapshot) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
+}
+
+// ProfileResponse represents a response naming file captured profile was written to.
+type ProfileResponse struct {
+	Profile string `json:"profile" proto:"1"` // one of cpu, heap or trace
+	File    string `json:"file" proto:"2"`    // path of the file on the server
+}
+
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *ProfileResponse) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
+}
@@ -7,40 +7,41 @@ import (
 
 // Error represents an error response.
 type Error struct {
-	Message string `json:"error"`
+	Message   string `json:"error" proto:"1"`
+	RequestID string `json:"request_id,omitempty" proto:"2"` // ID of the request that caused the error
 }
 
-// MarshalHTTP implements http.Marshaler.
-func (r *Error) MarshalHTTP(w http.ResponseWriter) error {
-	return json.NewEncoder(w).Encode(r)
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *Error) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
 }
 
 // CurrentFibonacciNumberResponse represents a response for getting current number in the Fibonacci sequence.
 type CurrentFibonacciNumberResponse struct {
-	Current int64 `json:"current"`
+	Current int64 `json:"current" proto:"1"`
 }
 
-// MarshalHTTP implements http.Marshaler.
-func (r *CurrentFibonacciNumberResponse) MarshalHTTP(w http.ResponseWriter) error {
-	return json.NewEncoder(w).Encode(r)
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *CurrentFibonacciNumberResponse) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
 }
 
 // NextFibonacciNumberResponse represents a response for getting next number in the Fibonacci sequence.
 type NextFibonacciNumberResponse struct {
-	Next int64 `json:"next"`
+	Next int64 `json:"next" proto:"1"`
 }
 
-// MarshalHTTP implements http.Marshaler.
-func (r *NextFibonacciNumberResponse) MarshalHTTP(w http.ResponseWriter) error {
-	return json.NewEncoder(w).Encode(r)
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *NextFibonacciNumberResponse) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
 }
 
 // PreviousFibonacciNumberResponse represents a response for getting previous number in the Fibonacci sequence.
 type PreviousFibonacciNumberResponse struct {
-	Previous int64 `json:"previous"`
+	Previous int64 `json:"previous" proto:"1"`
 }
 
-// MarshalHTTP implements http.Marshaler.
-func (r *PreviousFibonacciNumberResponse) MarshalHTTP(w http.ResponseWriter) error {
-	return json.NewEncoder(w).Encode(r)
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *PreviousFibonacciNumberResponse) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
 }
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Health statuses.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// HealthResponse represents a response of liveness and readiness probes.
+type HealthResponse struct {
+	Status string            `json:"status" proto:"1"`
+	Checks map[string]string `json:"checks,omitempty" proto:"2"` // check name to its status or error
+}
+
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *HealthResponse) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
+}
@@ -0,0 +1,29 @@
+package api
+
+// WebSocket command names accepted by the stepping interface.
+const (
+	CommandCurrent  = "current"
+	CommandNext     = "next"
+	CommandPrevious = "previous"
+	CommandSeek     = "seek"
+)
+
+// WebSocket event types sent by the stepping interface.
+const (
+	EventResult = "result" // response to the command sent by the same client
+	EventUpdate = "update" // counter was moved by another client
+)
+
+// StepCommand represents a command sent by the client over WebSocket connection.
+type StepCommand struct {
+	Command string `json:"command"`
+	Term    int    `json:"term,omitempty"` // target term for seek command
+}
+
+// StepEvent represents a message sent to the client over WebSocket connection.
+type StepEvent struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Value   int64  `json:"value"`
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SequenceFibonacci names the Fibonacci sequence in Number responses.
+const SequenceFibonacci = "fibonacci"
+
+// Error represents an error response.
+type Error struct {
+	Message   string `json:"error" proto:"1"`
+	RequestID string `json:"request_id,omitempty" proto:"2"` // ID of the request that caused the error
+}
+
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *Error) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
+}
+
+// Number represents a response carrying a number of a sequence, every route stepping through the sequence responds with it.
+type Number struct {
+	Index    int    `json:"index" proto:"1"`    // position of the number in the sequence
+	Value    string `json:"value" proto:"2"`    // decimal number, a string so clients decoding JSON numbers as doubles do not lose precision
+	Sequence string `json:"sequence" proto:"3"` // name of the sequence
+}
+
+// UnmarshalHTTPResponse implements http.ResponseUnmarshaler.
+func (r *Number) UnmarshalHTTPResponse(resp *http.Response) error {
+	return json.NewDecoder(resp.Body).Decode(r)
+}
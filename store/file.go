@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+// ErrStateNotFound is returned when no counter state was persisted yet.
+var ErrStateNotFound = errors.New("counter state was not found")
+
+// Config represents counter state persistence configuration.
+type Config struct {
+	Path string `mapstructure:"path"` // file counter state is persisted to, persistence is disabled when empty
+}
+
+// State is persisted counter state.
+type State struct {
+	Position int       `json:"position"` // term counter points to
+	SavedAt  time.Time `json:"saved_at"` // time state was saved at
+}
+
+// File persists counter state in a JSON file.
+// File is replaced atomically, so a crash while saving leaves either the previous or the new state behind.
+type File struct {
+	path string
+}
+
+// NewFile constructs a new File persisting state to file at path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Load loads persisted state, ErrStateNotFound is returned when there is none.
+func (f *File) Load() (State, error) {
+	var state State
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, errors.Wrapf(ErrStateNotFound, "%s", f.path)
+		}
+		return state, errors.Wrapf(err, "reading counter state %s", f.path)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, errors.Wrapf(err, "parsing counter state %s", f.path)
+	}
+	return state, nil
+}
+
+// Save persists state, it is flushed to disk before Save returns.
+func (f *File) Save(state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "marshaling counter state")
+	}
+
+	dir := filepath.Dir(f.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "creating counter state %s", f.path)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "writing counter state %s", f.path)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "flushing counter state %s", f.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "writing counter state %s", f.path)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return errors.Wrapf(err, "replacing counter state %s", f.path)
+	}
+
+	// rename itself is durable only once directory is flushed
+	d, err := os.Open(dir)
+	if err != nil {
+		return errors.Wrapf(err, "flushing counter state directory %s", dir)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return errors.Wrapf(err, "flushing counter state directory %s", dir)
+	}
+	return nil
+}
+
+// Check reports whether state is able to be saved, that is whether directory of the state file is reachable and
+// writable. It implements http.Checker, so it backs readiness probe.
+func (f *File) Check(ctx context.Context) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".check.*")
+	if err != nil {
+		return errors.Wrapf(err, "counter state directory of %s is not writable", f.path)
+	}
+	tmp.Close()
+
+	if err := os.Remove(tmp.Name()); err != nil {
+		return errors.Wrapf(err, "removing counter state check file %s", tmp.Name())
+	}
+	return nil
+}
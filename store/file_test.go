@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	file := NewFile(filepath.Join(dir, "state.json"))
+
+	if _, err := file.Load(); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("got %v, want %v", err, ErrStateNotFound)
+	}
+
+	saved := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, position := range []int{10, 42} {
+		if err := file.Save(State{Position: position, SavedAt: saved}); err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+
+		state, err := file.Load()
+		if err != nil {
+			t.Fatalf("got %v, want %v", err, nil)
+		}
+		if state.Position != position || !state.SavedAt.Equal(saved) {
+			t.Errorf("got %v, want %v", state, State{Position: position, SavedAt: saved})
+		}
+	}
+
+	// temporary files are not left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %v, want %v", len(entries), 1)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "state.json"), []byte(`{"position":`), 0o600); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if _, err := file.Load(); err == nil || errors.Is(err, ErrStateNotFound) {
+		t.Errorf("got %v, want parsing error", err)
+	}
+}
+
+func TestFileCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	var testcases = []struct {
+		path string
+
+		ok bool
+	}{
+		{filepath.Join(dir, "state.json"), true},
+		{filepath.Join(dir, "missing", "state.json"), false},
+	}
+
+	for i, tt := range testcases {
+		err := NewFile(tt.path).Check(context.Background())
+		if (err == nil) != tt.ok {
+			t.Errorf("#%d got %v, want ok %v", i, err, tt.ok)
+		}
+	}
+
+	// check files are not left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %v, want %v", len(entries), 0)
+	}
+}
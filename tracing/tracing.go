@@ -0,0 +1,206 @@
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/deividaspetraitis/fibonacci/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Supported exporters.
+const (
+	ExporterNone   = "none"   // spans are not exported, trace context is propagated only
+	ExporterStdout = "stdout" // spans are pretty printed to standard output
+	ExporterFile   = "file"   // spans are appended to a file in OTLP JSON format
+)
+
+// Config represents tracing configuration.
+type Config struct {
+	Exporter string `mapstructure:"exporter"` // one of none, stdout or file
+	File     string `mapstructure:"file"`     // PATH to the OTLP JSON file used by file exporter
+}
+
+// Validate checks whether settings of c are sane and returns all problems found.
+func (c *Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterNone, ExporterStdout:
+		return nil
+	case ExporterFile:
+		if c.File == "" {
+			return errors.New("tracing file must be set when exporter is file")
+		}
+		return nil
+	default:
+		return errors.Newf("tracing exporter %q is unknown", c.Exporter)
+	}
+}
+
+// ShutdownFunc flushes pending spans and stops exporting them.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs global W3C trace context propagator and tracer provider exporting spans as configured by cfg.
+func Setup(ctx context.Context, service string, cfg *Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch {
+	case cfg == nil, cfg.Exporter == "", cfg.Exporter == ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case cfg.Exporter == ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, errors.Wrap(err, "constructing stdout exporter")
+		}
+		exporter = exp
+	case cfg.Exporter == ExporterFile:
+		if cfg.File == "" {
+			return nil, errors.New("file exporter requires file path")
+		}
+		exp, err := otlptrace.New(ctx, &fileClient{path: cfg.File})
+		if err != nil {
+			return nil, errors.Wrapf(err, "constructing file exporter %s", cfg.File)
+		}
+		exporter = exp
+	default:
+		return nil, errors.Newf("unknown exporter %q", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(service),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// fileClient implements otlptrace.Client appending each batch of spans to a file
+// as a single line of OTLP JSON, the format used by OpenTelemetry Collector file exporter.
+type fileClient struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Start implements otlptrace.Client.
+func (c *fileClient) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	c.file = f
+
+	return nil
+}
+
+// Stop implements otlptrace.Client.
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+
+	return err
+}
+
+// UploadTraces implements otlptrace.Client.
+func (c *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	data, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: spans,
+	})
+	if err != nil {
+		return errors.Wrap(err, "encoding spans")
+	}
+
+	data, err = hexIDs(data)
+	if err != nil {
+		return errors.Wrap(err, "encoding span IDs")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return errors.New("file exporter is stopped")
+	}
+	_, err = c.file.Write(append(data, '\n'))
+
+	return err
+}
+
+// hexIDs re-encodes trace and span IDs of OTLP JSON document data from base64, used by protojson for bytes fields,
+// to hex as required by OTLP JSON encoding.
+func hexIDs(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var walk func(v interface{}) error
+	walk = func(v interface{}) error {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				switch id, ok := value.(string); {
+				case ok && (key == "traceId" || key == "spanId" || key == "parentSpanId"):
+					b, err := base64.StdEncoding.DecodeString(id)
+					if err != nil {
+						return errors.Wrapf(err, "decoding %s", key)
+					}
+					v[key] = hex.EncodeToString(b)
+				default:
+					if err := walk(value); err != nil {
+						return err
+					}
+				}
+			}
+		case []interface{}:
+			for _, value := range v {
+				if err := walk(value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc)
+}